@@ -0,0 +1,61 @@
+package eventual
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// FromOnce creates a Value that's populated by f, for example a function returned by
+// sync.OnceValues. f is called in the background right away and the Value is set to its result. If
+// f fails, the Value fails with its error, see Value.SetError.
+func FromOnce[V comparable](f func() (V, error), opts ...Option) Value[V] {
+	v := newValue[V](opts)
+	go func() {
+		result, err := f()
+		v.m.Lock()
+		v.recordError(err)
+		v.m.Unlock()
+		if err != nil {
+			v.SetError(err)
+		} else {
+			v.Set(result)
+		}
+	}()
+	return v
+}
+
+// FromAtomic creates a Value that bridges to a. If a already holds a value, the new Value starts
+// out set to it. Every subsequent Set of the Value is written through to a, so code that still reads
+// a directly observes the updates. Values stored in a directly aren't observed by the Value.
+//
+// The usual restrictions of atomic.Value apply: V must not be nil and must always have the same
+// concrete type.
+func FromAtomic[V comparable](a *atomic.Value, opts ...Option) Value[V] {
+	v := newValue[V](opts)
+	if current := a.Load(); current != nil {
+		v.Set(current.(V))
+	}
+	v.onUpdate = func(u Update[V]) {
+		if u.Kind == UpdateSet {
+			a.Store(u.Value)
+		}
+	}
+	return v
+}
+
+// AsOnce returns a function that waits for v to be set and then returns the same result on every
+// call, like the functions returned by sync.OnceValues.
+func AsOnce[V comparable](v Value[V]) func() (V, error) {
+	var (
+		once   sync.Once
+		result V
+		err    error
+	)
+	return func() (V, error) {
+		once.Do(func() {
+			result, err = v.Get(context.Background())
+		})
+		return result, err
+	}
+}
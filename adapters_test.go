@@ -0,0 +1,95 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromOnce(t *testing.T) {
+	var calls int32
+	v := FromOnce(func() (string, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		return "computed", nil
+	})
+
+	_, err := v.Get(DontWait)
+	require.Error(t, err, "value shouldn't be available before f returns")
+	r, err := v.Get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "computed", r)
+	r, err = v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "computed", r)
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+	failing := FromOnce(func() (string, error) {
+		return "", errors.New("failed")
+	})
+	_, err = failing.Get(context.Background())
+	require.EqualError(t, err, "failed", "waiting Get should be released when f fails")
+	_, err = failing.Get(DontWait)
+	require.EqualError(t, err, "failed")
+	lastErr, _ := failing.LastError()
+	require.EqualError(t, lastErr, "failed")
+}
+
+func TestFromOnceWithoutGet(t *testing.T) {
+	v := FromOnce(func() (string, error) {
+		return "computed", nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, v.Wait(ctx), "f should run without a Get")
+	require.NoError(t, v.Check(ctx))
+	r, ok := v.Peek()
+	require.True(t, ok)
+	require.Equal(t, "computed", r)
+
+	failing := FromOnce(func() (string, error) {
+		return "", errors.New("failed")
+	})
+	require.EqualError(t, failing.Wait(ctx), "failed")
+	require.EqualError(t, failing.Check(ctx), "failed")
+}
+
+func TestFromAtomic(t *testing.T) {
+	var a atomic.Value
+	a.Store("initial")
+	v := FromAtomic[string](&a)
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "initial", r)
+
+	v.Set("updated")
+	require.Equal(t, "updated", a.Load())
+
+	var empty atomic.Value
+	v = FromAtomic[string](&empty)
+	_, err = v.Get(DontWait)
+	require.Error(t, err)
+	v.Set("set")
+	require.Equal(t, "set", empty.Load())
+}
+
+func TestAsOnce(t *testing.T) {
+	v := NewValue[int]()
+	once := AsOnce(v)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		v.Set(1)
+	}()
+	r, err := once()
+	require.NoError(t, err)
+	require.Equal(t, 1, r)
+
+	v.Set(2)
+	r, err = once()
+	require.NoError(t, err)
+	require.Equal(t, 1, r, "result should be computed only once")
+}
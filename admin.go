@@ -0,0 +1,228 @@
+package eventual
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sync"
+)
+
+// errNoSuchTarget is returned for requests that name something that isn't registered.
+var errNoSuchTarget = errors.New("no such value")
+
+// errNothingToExpire is returned for requests to expire something that isn't set.
+var errNothingToExpire = errors.New("value isn't set")
+
+// maxAdminBody is the size limit for the bodies of requests to an Admin.
+const maxAdminBody = 1 << 20
+
+// Admin is an http.Handler that lets operators force-set, force-expire or reset named Values and
+// Map keys at runtime, as an escape hatch for when a producer is wedged and a manual value would
+// unblock its consumers. Nothing can be changed through it unless it's explicitly registered with
+// AdminValue or AdminMap, and every request must be authorized.
+//
+// It accepts POST requests whose path ends in /set, /expire or /reset, naming what to change with
+// the name query parameter, and for Maps the key with the key parameter. The body of /set holds the
+// new value, up to 1 MiB. Only keys that a Map is already tracking can be expired or reset.
+// Requests fail with 409 Conflict if the Value is frozen or there's nothing to expire, and with
+// 410 Gone if it's closed.
+type Admin struct {
+	authorize func(req *http.Request) bool
+	mx        sync.RWMutex
+	targets   map[string]adminTarget
+}
+
+// adminTarget is something registered with an Admin.
+type adminTarget interface {
+	set(key string, data []byte) error
+	expire(key string) error
+	reset(key string) error
+}
+
+// NewAdmin creates an Admin that serves the requests for which authorize returns true, and responds
+// to all others with 403 Forbidden. It panics if authorize is nil.
+func NewAdmin(authorize func(req *http.Request) bool) *Admin {
+	if authorize == nil {
+		panic("eventual: NewAdmin requires an authorize function")
+	}
+	return &Admin{authorize: authorize, targets: make(map[string]adminTarget)}
+}
+
+func (a *Admin) add(name string, target adminTarget) {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	a.targets[name] = target
+}
+
+// AdminValue registers v with a under the given name. Values that are set through a are decoded
+// with codec, or as JSON if codec is nil.
+func AdminValue[V comparable](a *Admin, name string, v Value[V], codec Codec[V]) {
+	if codec == nil {
+		codec = JSONCodec[V]{}
+	}
+	a.add(name, &adminValue[V]{v: v, codec: codec})
+}
+
+// AdminMap registers m with a under the given name. Keys and values are decoded with the Map's
+// codecs (see WithKeyCodec and WithCodec), which default to JSON, so string keys need to be quoted.
+func AdminMap[K comparable, V comparable](a *Admin, name string, m Map[K, V]) {
+	target := &adminMap[K, V]{m: m, keyCodec: JSONCodec[K]{}, codec: JSONCodec[V]{}}
+	if c, ok := m.(interface {
+		codecs() (Codec[K], Codec[V])
+	}); ok {
+		target.keyCodec, target.codec = c.codecs()
+	}
+	a.add(name, target)
+}
+
+func (a *Admin) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	resp.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !a.authorize(req) {
+		http.Error(resp, "forbidden", http.StatusForbidden)
+		return
+	}
+	if req.Method != http.MethodPost {
+		resp.Header().Set("Allow", http.MethodPost)
+		http.Error(resp, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := req.URL.Query().Get("name")
+	key := req.URL.Query().Get("key")
+	a.mx.RLock()
+	target := a.targets[name]
+	a.mx.RUnlock()
+	if target == nil {
+		http.Error(resp, fmt.Sprintf("%v: %v", errNoSuchTarget, name), http.StatusNotFound)
+		return
+	}
+
+	var err error
+	switch path.Base(req.URL.Path) {
+	case "set":
+		var data []byte
+		data, err = io.ReadAll(http.MaxBytesReader(resp, req.Body, maxAdminBody))
+		if err == nil {
+			err = target.set(key, data)
+		}
+	case "expire":
+		err = target.expire(key)
+	case "reset":
+		err = target.reset(key)
+	default:
+		http.NotFound(resp, req)
+		return
+	}
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		http.Error(resp, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, errNoSuchTarget):
+		http.Error(resp, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrFrozen), errors.Is(err, errNothingToExpire):
+		http.Error(resp, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrClosed):
+		http.Error(resp, err.Error(), http.StatusGone)
+	case err != nil:
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+	default:
+		fmt.Fprintln(resp, "ok")
+	}
+}
+
+type adminValue[V comparable] struct {
+	v     Value[V]
+	codec Codec[V]
+}
+
+func (a *adminValue[V]) set(_ string, data []byte) error {
+	value, err := a.codec.Decode(data)
+	if err != nil {
+		return err
+	}
+	return a.v.TrySet(value)
+}
+
+func (a *adminValue[V]) expire(string) error {
+	v, ok := a.v.(interface{ forceExpire() bool })
+	if !ok {
+		return errors.New("value can't be expired")
+	}
+	if !v.forceExpire() {
+		return errNothingToExpire
+	}
+	return nil
+}
+
+func (a *adminValue[V]) reset(string) error {
+	a.v.Reset()
+	return nil
+}
+
+type adminMap[K comparable, V comparable] struct {
+	m        Map[K, V]
+	keyCodec Codec[K]
+	codec    Codec[V]
+}
+
+func (a *adminMap[K, V]) set(key string, data []byte) error {
+	k, err := a.keyCodec.Decode([]byte(key))
+	if err != nil {
+		return err
+	}
+	decoded, err := a.codec.Decode(data)
+	if err != nil {
+		return err
+	}
+	m, ok := a.m.(interface{ getValue(key K) *value[V] })
+	if !ok {
+		a.m.Set(k, decoded)
+		return nil
+	}
+	return m.getValue(k).TrySet(decoded)
+}
+
+// existing decodes key and returns it along with its value, or an error if the Map isn't tracking
+// it.
+func (a *adminMap[K, V]) existing(key string) (K, *value[V], error) {
+	k, err := a.keyCodec.Decode([]byte(key))
+	if err != nil {
+		return k, nil, err
+	}
+	m, ok := a.m.(interface{ existingValue(key K) *value[V] })
+	if !ok {
+		return k, nil, errors.New("map can't be changed")
+	}
+	v := m.existingValue(k)
+	if v == nil {
+		return k, nil, fmt.Errorf("%w: %v", errNoSuchTarget, key)
+	}
+	return k, v, nil
+}
+
+func (a *adminMap[K, V]) expire(key string) error {
+	_, v, err := a.existing(key)
+	if err != nil {
+		return err
+	}
+	if !v.forceExpire() {
+		return errNothingToExpire
+	}
+	return nil
+}
+
+func (a *adminMap[K, V]) reset(key string) error {
+	k, _, err := a.existing(key)
+	if err != nil {
+		return err
+	}
+	// Reset through the Map, which may have to do more than resetting the value
+	a.m.Reset(k)
+	return nil
+}
+
+func (m *emap[K, V]) codecs() (Codec[K], Codec[V]) {
+	return m.keyCodec, m.codec
+}
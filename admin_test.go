@@ -0,0 +1,88 @@
+package eventual
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmin(t *testing.T) {
+	a := NewAdmin(func(req *http.Request) bool {
+		return req.Header.Get("Authorization") == "Bearer secret"
+	})
+	config := NewValue[int]()
+	AdminValue(a, "config", config, nil)
+	users := NewMap[string, string]()
+	AdminMap(a, "users", users)
+
+	post := func(method string, query url.Values, body string, authorized bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/debug/eventual/"+method+"?"+query.Encode(), strings.NewReader(body))
+		if authorized {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("set", url.Values{"name": {"config"}}, "5", false)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.False(t, config.IsSet())
+
+	rec = post("set", url.Values{"name": {"config"}}, "5", true)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	r, err := config.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, 5, r)
+
+	require.Equal(t, http.StatusBadRequest, post("set", url.Values{"name": {"config"}}, "five", true).Code)
+	require.Equal(t, http.StatusRequestEntityTooLarge, post("set", url.Values{"name": {"config"}}, strings.Repeat("1", maxAdminBody+1), true).Code)
+	require.Equal(t, 5, config.MustGet(DontWait), "oversized body shouldn't change the value")
+	require.Equal(t, http.StatusNotFound, post("set", url.Values{"name": {"other"}}, "5", true).Code)
+	require.Equal(t, http.StatusNotFound, post("frobnicate", url.Values{"name": {"config"}}, "", true).Code)
+
+	require.Equal(t, http.StatusOK, post("expire", url.Values{"name": {"config"}}, "", true).Code)
+	require.True(t, config.IsExpired())
+	require.Equal(t, http.StatusOK, post("reset", url.Values{"name": {"config"}}, "", true).Code)
+	require.False(t, config.IsExpired())
+
+	rec = post("set", url.Values{"name": {"users"}, "key": {`"alice"`}}, `"admin"`, true)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	role, err := users.Get(DontWait, "alice")
+	require.NoError(t, err)
+	require.Equal(t, "admin", role)
+	require.Equal(t, http.StatusOK, post("expire", url.Values{"name": {"users"}, "key": {`"alice"`}}, "", true).Code)
+	_, err = users.Get(DontWait, "alice")
+	require.Error(t, err)
+	require.Equal(t, http.StatusNotFound, post("expire", url.Values{"name": {"users"}, "key": {`"bob"`}}, "", true).Code)
+	require.Equal(t, http.StatusBadRequest, post("reset", url.Values{"name": {"users"}, "key": {"bob"}}, "", true).Code, "unquoted key isn't valid JSON")
+
+	require.Equal(t, http.StatusNotFound, post("reset", url.Values{"name": {"users"}, "key": {`"bob"`}}, "", true).Code)
+	require.Equal(t, 1, users.Stats().Keys, "resetting an unknown key shouldn't track it")
+
+	require.Equal(t, http.StatusConflict, post("expire", url.Values{"name": {"config"}}, "", true).Code, "unset value can't be expired")
+	config.Freeze()
+	rec = post("set", url.Values{"name": {"config"}}, "6", true)
+	require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+	require.False(t, config.IsSet(), "frozen value shouldn't be set")
+	closed := NewValue[int]()
+	closed.Close()
+	AdminValue(a, "closed", closed, nil)
+	require.Equal(t, http.StatusGone, post("set", url.Values{"name": {"closed"}}, "6", true).Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/eventual/set?name=config", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestNewAdminRequiresAuthorize(t *testing.T) {
+	require.PanicsWithValue(t, "eventual: NewAdmin requires an authorize function", func() {
+		NewAdmin(nil)
+	})
+}
@@ -0,0 +1,446 @@
+package eventual
+
+import (
+	"context"
+	"iter"
+	"time"
+)
+
+// AnyValue is like Value, but can hold values of any type, including slices, maps, funcs and
+// structs containing them. It has the same API as Value, except for EqualTo and CompareAndSet,
+// which need to compare values. Options that are parameterized by the value type, such as
+// WithWeigher, WithComparator, WithFallback and WithQuorum, aren't supported.
+type AnyValue[V any] interface {
+	// Set this AnyValue.
+	Set(value V)
+
+	// Set this AnyValue, expiring at the given time.
+	SetExpiring(value V, expiration time.Time)
+
+	// SetExpiringIn sets this AnyValue, expiring ttl from now, see Value.SetExpiringIn.
+	SetExpiringIn(value V, ttl time.Duration)
+
+	// Touch moves the expiration of the current value without rewriting it, see Value.Touch.
+	Touch(newExpiration time.Time) bool
+
+	// ScheduleSet sets this AnyValue at the given time unless cancelled, see Value.ScheduleSet.
+	ScheduleSet(value V, at time.Time) (cancel func())
+
+	// TrySet is like Set but returns an error if the AnyValue doesn't accept writes, see
+	// Value.TrySet.
+	TrySet(value V) error
+
+	// SetError makes the AnyValue fail with err, see Value.SetError.
+	SetError(err error)
+
+	// Fail releases the callers currently waiting with err, see Value.Fail.
+	Fail(err error)
+
+	// Reset clears the currently set value, see Value.Reset.
+	Reset()
+
+	// Freeze makes the AnyValue permanently read-only, see Value.Freeze.
+	Freeze()
+
+	// Close permanently terminates the AnyValue, see Value.Close.
+	Close()
+
+	// Get waits for the value to be set, see Value.Get.
+	Get(ctx context.Context) (V, error)
+
+	// GetOrElse is like Get, but returns fallback if no value is available in time, see
+	// Value.GetOrElse.
+	GetOrElse(ctx context.Context, fallback V) V
+
+	// HasDefault reports whether the AnyValue has a default (see WithAnyDefault).
+	HasDefault() bool
+
+	// Wait waits until the AnyValue is set without returning the value, see Value.Wait.
+	Wait(ctx context.Context) error
+
+	// Check returns nil if the AnyValue holds an unexpired value, and otherwise why not, see
+	// Value.Check.
+	Check(ctx context.Context) error
+
+	// ReadOnly returns a view of the AnyValue that can only be read, see Value.ReadOnly.
+	ReadOnly() Getter[V]
+
+	// WriteOnly returns a view of the AnyValue that can only be written, see Value.WriteOnly.
+	WriteOnly() Setter[V]
+
+	// GetStable is like Get, but only returns a value once it has remained set for at least
+	// stableFor, see Value.GetStable. Since payloads can't be compared, every Set restarts the
+	// wait.
+	GetStable(ctx context.Context, stableFor time.Duration) (V, error)
+
+	// MustGet is like Get, but panics if no value is available, see Value.MustGet.
+	MustGet(ctx context.Context) V
+
+	// Peek returns the current value without blocking, see Value.Peek.
+	Peek() (V, bool)
+
+	// IsSet reports whether the AnyValue holds an unexpired value, see Value.IsSet.
+	IsSet() bool
+
+	// IsExpired reports whether the AnyValue was set but has since expired, see Value.IsExpired.
+	IsExpired() bool
+
+	// Expiration returns when the current value expires, see Value.Expiration.
+	Expiration() (time.Time, bool)
+
+	// GetWithBudget is like Get, but stops waiting reserve before the context's deadline, see
+	// Value.GetWithBudget.
+	GetWithBudget(ctx context.Context, reserve time.Duration) (V, error)
+
+	// SetExpiringSoft sets this AnyValue with distinct soft and hard expirations, see
+	// Value.SetExpiringSoft.
+	SetExpiringSoft(value V, softExpiration time.Time, hardExpiration time.Time)
+
+	// GetOrSetSoftExpiring is like GetOrSetExpiring, but serves stale values while refreshing
+	// them in the background, see Value.GetOrSetSoftExpiring.
+	GetOrSetSoftExpiring(softExpiration time.Time, hardExpiration time.Time, getter func() (V, error)) (V, error)
+
+	// GetOrSetWithFreshness is like GetOrSetSoftExpiring, but also reports the freshness of the
+	// result, see Value.GetOrSetWithFreshness.
+	GetOrSetWithFreshness(softExpiration time.Time, hardExpiration time.Time, getter func() (V, error)) (V, Freshness, error)
+
+	// GetOrCompute waits for the AnyValue to be set, computing it if that takes too long, see
+	// Value.GetOrCompute.
+	GetOrCompute(ctx context.Context, compute func(context.Context) (V, error)) (V, error)
+
+	// GetOrSetExpiring returns the stored value, or stores and returns the result of getter, see
+	// Value.GetOrSetExpiring.
+	GetOrSetExpiring(expiration time.Time, getter func() (V, error)) (V, error)
+
+	// NotifyExpiry returns a channel that's closed once the current value stops being current,
+	// see Value.NotifyExpiry.
+	NotifyExpiry() <-chan struct{}
+
+	// OnExpire registers fn to be called whenever a value lapses, see Value.OnExpire.
+	OnExpire(fn func(V))
+
+	// Updates returns an iterator over the updates to this AnyValue, see Value.Updates.
+	Updates(ctx context.Context) iter.Seq[V]
+
+	// Watch is like Updates, but delivers the updates on a channel, see Value.Watch.
+	Watch(ctx context.Context) <-chan V
+
+	// Waiters returns the number of callers currently waiting for this AnyValue to be set.
+	Waiters() int
+
+	// LastError returns the error from the most recent attempt to populate the AnyValue, see
+	// Value.LastError.
+	LastError() (error, time.Time)
+
+	// Version returns the AnyValue's current version, see Value.Version.
+	Version() uint64
+
+	// Changed reports whether the AnyValue has been Set or Reset since it was at the given
+	// version, see Value.Changed.
+	Changed(since uint64) bool
+
+	// GetAtLeast is like Get, but first waits for the AnyValue to reach at least minVersion, see
+	// Value.GetAtLeast.
+	GetAtLeast(ctx context.Context, minVersion uint64) (V, error)
+
+	// GetValid is like Get, but only returns a value that passes validate, see Value.GetValid.
+	GetValid(ctx context.Context, validate func(V) error) (V, error)
+
+	// Pin returns the current value and keeps it from expiring until unpin is called, see
+	// Value.Pin.
+	Pin() (value V, unpin func(), err error)
+
+	// History returns the most recent values, oldest first, see Value.History.
+	History() []Entry[V]
+
+	// SetWithProvenance is like Set, but records where the value came from, see
+	// Value.SetWithProvenance.
+	SetWithProvenance(value V, provenance Provenance)
+
+	// Provenance returns the provenance of the current value, see Value.Provenance.
+	Provenance() Provenance
+
+	// SetIfAbsent sets the AnyValue only if it isn't currently set, see Value.SetIfAbsent.
+	SetIfAbsent(value V) bool
+
+	// Update atomically replaces the AnyValue with the result of fn, see Value.Update.
+	Update(fn func(current V, set bool) V)
+
+	// Clone returns an independent AnyValue with the same configuration, default and current
+	// value, see Value.Clone.
+	Clone() AnyValue[V]
+
+	// Weight always returns 0, since AnyValue doesn't support WithWeigher.
+	Weight() int
+}
+
+// NewAnyValue creates a new AnyValue.
+func NewAnyValue[V any](opts ...Option) AnyValue[V] {
+	return &anyValue[V]{NewValue[*box[V]](opts...)}
+}
+
+// WithAnyDefault creates a new AnyValue that returns the given defaultValue if a real value isn't
+// available in time, like WithDefault.
+func WithAnyDefault[V any](defaultValue V, opts ...Option) AnyValue[V] {
+	return &anyValue[V]{WithDefault(&box[V]{defaultValue}, opts...)}
+}
+
+// box holds a value of any type in a comparable pointer. Every Set stores a new box, so boxes
+// compare equal only if they're the same Set.
+type box[V any] struct {
+	v V
+}
+
+// unbox returns the value in b, or the zero value if b is nil.
+func unbox[V any](b *box[V]) V {
+	if b == nil {
+		var zero V
+		return zero
+	}
+	return b.v
+}
+
+// boxed adapts a getter to a Value of boxes.
+func boxed[V any](getter func() (V, error)) func() (*box[V], error) {
+	return func() (*box[V], error) {
+		v, err := getter()
+		if err != nil {
+			return nil, err
+		}
+		return &box[V]{v}, nil
+	}
+}
+
+// anyValue implements AnyValue with a Value of boxes.
+type anyValue[V any] struct {
+	v Value[*box[V]]
+}
+
+func (a *anyValue[V]) Set(value V) {
+	a.v.Set(&box[V]{value})
+}
+
+func (a *anyValue[V]) SetExpiring(value V, expiration time.Time) {
+	a.v.SetExpiring(&box[V]{value}, expiration)
+}
+
+func (a *anyValue[V]) SetExpiringIn(value V, ttl time.Duration) {
+	a.v.SetExpiringIn(&box[V]{value}, ttl)
+}
+
+func (a *anyValue[V]) Touch(newExpiration time.Time) bool {
+	return a.v.Touch(newExpiration)
+}
+
+func (a *anyValue[V]) ScheduleSet(value V, at time.Time) func() {
+	return a.v.ScheduleSet(&box[V]{value}, at)
+}
+
+func (a *anyValue[V]) TrySet(value V) error {
+	return a.v.TrySet(&box[V]{value})
+}
+
+func (a *anyValue[V]) SetError(err error) {
+	a.v.SetError(err)
+}
+
+func (a *anyValue[V]) Fail(err error) {
+	a.v.Fail(err)
+}
+
+func (a *anyValue[V]) Reset() {
+	a.v.Reset()
+}
+
+func (a *anyValue[V]) Freeze() {
+	a.v.Freeze()
+}
+
+func (a *anyValue[V]) Close() {
+	a.v.Close()
+}
+
+func (a *anyValue[V]) Get(ctx context.Context) (V, error) {
+	b, err := a.v.Get(ctx)
+	return unbox(b), err
+}
+
+func (a *anyValue[V]) GetOrElse(ctx context.Context, fallback V) V {
+	return a.v.GetOrElse(ctx, &box[V]{fallback}).v
+}
+
+func (a *anyValue[V]) HasDefault() bool {
+	return a.v.HasDefault()
+}
+
+func (a *anyValue[V]) Wait(ctx context.Context) error {
+	return a.v.Wait(ctx)
+}
+
+func (a *anyValue[V]) GetStable(ctx context.Context, stableFor time.Duration) (V, error) {
+	b, err := a.v.GetStable(ctx, stableFor)
+	return unbox(b), err
+}
+
+func (a *anyValue[V]) MustGet(ctx context.Context) V {
+	return a.v.MustGet(ctx).v
+}
+
+func (a *anyValue[V]) Peek() (V, bool) {
+	b, ok := a.v.Peek()
+	return unbox(b), ok
+}
+
+func (a *anyValue[V]) IsSet() bool {
+	return a.v.IsSet()
+}
+
+func (a *anyValue[V]) IsExpired() bool {
+	return a.v.IsExpired()
+}
+
+func (a *anyValue[V]) Expiration() (time.Time, bool) {
+	return a.v.Expiration()
+}
+
+func (a *anyValue[V]) GetWithBudget(ctx context.Context, reserve time.Duration) (V, error) {
+	b, err := a.v.GetWithBudget(ctx, reserve)
+	return unbox(b), err
+}
+
+func (a *anyValue[V]) SetExpiringSoft(value V, softExpiration time.Time, hardExpiration time.Time) {
+	a.v.SetExpiringSoft(&box[V]{value}, softExpiration, hardExpiration)
+}
+
+func (a *anyValue[V]) GetOrSetSoftExpiring(softExpiration time.Time, hardExpiration time.Time, getter func() (V, error)) (V, error) {
+	b, err := a.v.GetOrSetSoftExpiring(softExpiration, hardExpiration, boxed(getter))
+	return unbox(b), err
+}
+
+func (a *anyValue[V]) GetOrSetWithFreshness(softExpiration time.Time, hardExpiration time.Time, getter func() (V, error)) (V, Freshness, error) {
+	b, freshness, err := a.v.GetOrSetWithFreshness(softExpiration, hardExpiration, boxed(getter))
+	return unbox(b), freshness, err
+}
+
+func (a *anyValue[V]) GetOrCompute(ctx context.Context, compute func(context.Context) (V, error)) (V, error) {
+	b, err := a.v.GetOrCompute(ctx, func(ctx context.Context) (*box[V], error) {
+		return boxed(func() (V, error) { return compute(ctx) })()
+	})
+	return unbox(b), err
+}
+
+func (a *anyValue[V]) GetOrSetExpiring(expiration time.Time, getter func() (V, error)) (V, error) {
+	b, err := a.v.GetOrSetExpiring(expiration, boxed(getter))
+	return unbox(b), err
+}
+
+func (a *anyValue[V]) NotifyExpiry() <-chan struct{} {
+	return a.v.NotifyExpiry()
+}
+
+func (a *anyValue[V]) OnExpire(fn func(V)) {
+	a.v.OnExpire(func(b *box[V]) {
+		fn(b.v)
+	})
+}
+
+func (a *anyValue[V]) Updates(ctx context.Context) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for b := range a.v.Updates(ctx) {
+			if !yield(b.v) {
+				return
+			}
+		}
+	}
+}
+
+func (a *anyValue[V]) Watch(ctx context.Context) <-chan V {
+	boxes := a.v.Watch(ctx)
+	ch := make(chan V)
+	go func() {
+		defer close(ch)
+		for b := range boxes {
+			select {
+			case ch <- b.v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+func (a *anyValue[V]) Waiters() int {
+	return a.v.Waiters()
+}
+
+func (a *anyValue[V]) Check(ctx context.Context) error {
+	return a.v.Check(ctx)
+}
+
+func (a *anyValue[V]) LastError() (error, time.Time) {
+	return a.v.LastError()
+}
+
+func (a *anyValue[V]) Version() uint64 {
+	return a.v.Version()
+}
+
+func (a *anyValue[V]) Changed(since uint64) bool {
+	return a.v.Changed(since)
+}
+
+func (a *anyValue[V]) GetAtLeast(ctx context.Context, minVersion uint64) (V, error) {
+	b, err := a.v.GetAtLeast(ctx, minVersion)
+	return unbox(b), err
+}
+
+func (a *anyValue[V]) GetValid(ctx context.Context, validate func(V) error) (V, error) {
+	b, err := a.v.GetValid(ctx, func(b *box[V]) error {
+		return validate(unbox(b))
+	})
+	return unbox(b), err
+}
+
+func (a *anyValue[V]) Pin() (V, func(), error) {
+	b, unpin, err := a.v.Pin()
+	return unbox(b), unpin, err
+}
+
+func (a *anyValue[V]) History() []Entry[V] {
+	boxes := a.v.History()
+	if boxes == nil {
+		return nil
+	}
+	history := make([]Entry[V], len(boxes))
+	for i, e := range boxes {
+		history[i] = Entry[V]{Value: e.Value.v, Time: e.Time}
+	}
+	return history
+}
+
+func (a *anyValue[V]) SetWithProvenance(value V, provenance Provenance) {
+	a.v.SetWithProvenance(&box[V]{value}, provenance)
+}
+
+func (a *anyValue[V]) Provenance() Provenance {
+	return a.v.Provenance()
+}
+
+func (a *anyValue[V]) SetIfAbsent(value V) bool {
+	return a.v.SetIfAbsent(&box[V]{value})
+}
+
+func (a *anyValue[V]) Update(fn func(current V, set bool) V) {
+	a.v.Update(func(current *box[V], set bool) *box[V] {
+		return &box[V]{fn(unbox(current), set)}
+	})
+}
+
+func (a *anyValue[V]) Clone() AnyValue[V] {
+	return &anyValue[V]{a.v.Clone()}
+}
+
+func (a *anyValue[V]) Weight() int {
+	return a.v.Weight()
+}
@@ -0,0 +1,70 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnyValue(t *testing.T) {
+	v := NewAnyValue[[]string](WithHistory(2))
+	_, err := v.Get(DontWait)
+	require.True(t, errors.Is(err, ErrNotSet))
+	require.Equal(t, []string{"fallback"}, v.GetOrElse(DontWait, []string{"fallback"}))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		v.Set([]string{"a"})
+	}()
+	r, err := v.Get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, r)
+
+	v.Update(func(current []string, set bool) []string {
+		require.True(t, set)
+		return append(current, "b")
+	})
+	r, ok := v.Peek()
+	require.True(t, ok)
+	require.Equal(t, []string{"a", "b"}, r)
+	require.Len(t, v.History(), 2)
+	require.Equal(t, []string{"a", "b"}, v.History()[1].Value)
+
+	clone := v.Clone()
+	v.Reset()
+	require.False(t, v.IsSet())
+	r, err = clone.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, r)
+
+	r, err = v.GetOrSetExpiring(time.Now().Add(time.Minute), func() ([]string, error) {
+		return []string{"loaded"}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"loaded"}, r)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := v.Watch(ctx)
+	require.Equal(t, []string{"loaded"}, <-ch)
+	v.Set(nil)
+	require.Nil(t, <-ch)
+}
+
+func TestWithAnyDefault(t *testing.T) {
+	v := WithAnyDefault([]string{"default"})
+	require.True(t, v.HasDefault())
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, []string{"default"}, r)
+	require.False(t, v.IsSet(), "default shouldn't count as set")
+
+	v.Set([]string{"a"})
+	r, err = v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, r)
+	require.False(t, NewAnyValue[[]string]().HasDefault())
+}
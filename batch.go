@@ -0,0 +1,111 @@
+package eventual
+
+import (
+	"time"
+)
+
+// Batch accumulates changes to a Map and applies them together when committed, for producers that
+// reconcile many keys at a time (see Map.Batch). A Batch isn't safe for concurrent use.
+type Batch[K comparable, V comparable] interface {
+	// Set adds setting key to value to the batch.
+	Set(key K, value V)
+
+	// SetExpiring adds setting key to value until expiration to the batch.
+	SetExpiring(key K, value V, expiration time.Time)
+
+	// Reset adds resetting key to the batch.
+	Reset(key K)
+
+	// Commit applies the changes in the order in which they were added, locking each of the Map's
+	// shards only once, and delivers the resulting updates to each consumer of the Map's Events
+	// at once. The Batch is empty afterwards and can be reused.
+	Commit()
+}
+
+// batchOp is a change to one of the keys in a Batch.
+type batchOp[K comparable, V comparable] struct {
+	key        K
+	value      V
+	expiration time.Time
+	reset      bool
+}
+
+type batch[K comparable, V comparable] struct {
+	m   *emap[K, V]
+	ops []batchOp[K, V]
+}
+
+func (m *emap[K, V]) Batch() Batch[K, V] {
+	return &batch[K, V]{m: m}
+}
+
+func (b *batch[K, V]) Set(key K, value V) {
+	b.SetExpiring(key, value, b.m.clock.Now().Add(tenYears))
+}
+
+func (b *batch[K, V]) SetExpiring(key K, value V, expiration time.Time) {
+	b.ops = append(b.ops, batchOp[K, V]{key: key, value: value, expiration: expiration})
+}
+
+func (b *batch[K, V]) Reset(key K) {
+	b.ops = append(b.ops, batchOp[K, V]{key: key, reset: true})
+}
+
+func (b *batch[K, V]) Commit() {
+	ops := b.ops
+	b.ops = nil
+
+	// Look up all of the values first, locking each shard once
+	values := make(map[K]*value[V], len(ops))
+	byShard := make(map[*shard[K, V]][]K)
+	for _, op := range ops {
+		s := b.m.shardFor(op.key)
+		byShard[s] = append(byShard[s], op.key)
+	}
+	created := 0
+	for s, keys := range byShard {
+		s.mx.Lock()
+		for _, key := range keys {
+			v, isNew := b.m.valueIn(s, key)
+			values[key] = v
+			if isNew {
+				created++
+			}
+		}
+		s.mx.Unlock()
+	}
+	for i := 0; i < created; i++ {
+		b.m.created()
+	}
+
+	updates := make([]keyedUpdate[K, V], 0, len(ops))
+	var dropped []*value[V]
+	var errs []error
+	for _, op := range ops {
+		v := values[op.key]
+		v.m.Lock()
+		if err := v.writable(); err != nil {
+			v.m.Unlock()
+			dropped = append(dropped, v)
+			errs = append(errs, err)
+			continue
+		}
+		// Collect the update instead of publishing it right away
+		publish := v.onUpdate
+		v.onUpdate = func(u Update[V]) {
+			updates = append(updates, keyedUpdate[K, V]{op.key, u})
+		}
+		if op.reset {
+			v.doReset()
+		} else {
+			v.doSetExpiring(op.value, op.expiration)
+		}
+		v.onUpdate = publish
+		v.m.Unlock()
+	}
+	b.m.publishBatch(updates)
+
+	for i, v := range dropped {
+		v.reportMisuse(errs[i])
+	}
+}
@@ -0,0 +1,65 @@
+package eventual
+
+import (
+	"context"
+	"iter"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch(t *testing.T) {
+	m := NewMap[string, int]()
+	m.Set("b", 5)
+	m.Set("c", 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	next, stop := iter.Pull2(m.Events(ctx))
+	defer stop()
+
+	b := m.Batch()
+	b.Set("a", 1)
+	b.Set("b", 1)
+	b.Set("b", 2)
+	b.Reset("c")
+	_, err := m.Get(DontWait, "a")
+	require.Error(t, err, "changes shouldn't be applied before Commit")
+
+	go func() {
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&m.(*emap[string, int]).numSubscribers) == 1
+		}, time.Second, time.Millisecond)
+		b.Commit()
+	}()
+	var keys []string
+	updates := make(map[string]Update[int])
+	for i := 0; i < 3; i++ {
+		key, u, ok := next()
+		require.True(t, ok)
+		keys = append(keys, key)
+		updates[key] = u
+	}
+	require.Equal(t, []string{"a", "b", "c"}, keys)
+	require.Equal(t, 2, updates["b"].Value)
+	require.Equal(t, 5, updates["b"].Old, "updates within the batch should be coalesced")
+	require.Equal(t, UpdateReset, updates["c"].Kind)
+
+	r, err := m.Get(DontWait, "b")
+	require.NoError(t, err)
+	require.Equal(t, 2, r)
+	_, err = m.Get(DontWait, "c")
+	require.Error(t, err)
+}
+
+func TestBatchMisuse(t *testing.T) {
+	var dropped []error
+	m := NewMap[string, int](WithMisuseHandler(func(err error) { dropped = append(dropped, err) }))
+	m.Close()
+	b := m.Batch()
+	b.Set("a", 1)
+	b.Commit()
+	require.Equal(t, []error{ErrClosed}, dropped)
+}
@@ -0,0 +1,129 @@
+package eventual
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BindOptions configures Bind.
+type BindOptions struct {
+	// Tag is the struct tag that names the key for each field. Defaults to "eventual".
+	Tag string
+
+	// IgnoreMissing makes Bind leave fields untouched, rather than fail, when their key doesn't
+	// resolve in time and they have no default.
+	IgnoreMissing bool
+}
+
+// Bind populates the fields of the struct pointed to by dst from m. Each field tagged with
+// `eventual:"key"` is set to the value at key, waiting for it to be set until ctx is done. A field
+// can specify a default that's used if its key doesn't resolve in time, as in
+// `eventual:"timeout,default=5s"`.
+//
+// Values are converted to the field's type. Supported types are strings, bools, integers, floats,
+// time.Duration and anything implementing encoding.TextUnmarshaler. If any field can't be
+// populated, Bind sets the fields it can and returns an error describing the ones it couldn't.
+func Bind(ctx context.Context, dst interface{}, m Map[string, string], opts BindOptions) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("eventual: Bind requires a non-nil pointer to a struct, not %T", dst)
+	}
+	tag := opts.Tag
+	if tag == "" {
+		tag = "eventual"
+	}
+
+	rv = rv.Elem()
+	var errs []string
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		key, defaultValue, hasDefault := parseBindTag(field.Tag.Get(tag))
+		if key == "" || !field.IsExported() {
+			continue
+		}
+
+		s, err := m.Get(ctx, key)
+		if err != nil {
+			if !hasDefault {
+				if !opts.IgnoreMissing {
+					errs = append(errs, fmt.Sprintf("%v (key %q): %v", field.Name, key, err))
+				}
+				continue
+			}
+			s = defaultValue
+		}
+		if err := setField(rv.Field(i), s); err != nil {
+			errs = append(errs, fmt.Sprintf("%v (key %q): %v", field.Name, key, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("eventual: unable to bind %v", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// parseBindTag parses tags of the form "key" or "key,default=value".
+func parseBindTag(tag string) (key string, defaultValue string, hasDefault bool) {
+	key, rest, found := strings.Cut(tag, ",")
+	if found {
+		defaultValue, hasDefault = strings.CutPrefix(rest, "default=")
+	}
+	return key, defaultValue, hasDefault
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setField(field reflect.Value, s string) error {
+	if field.CanAddr() {
+		if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(s))
+		}
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.Type() == durationType {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return err
+			}
+			field.SetInt(int64(d))
+			return nil
+		}
+		i, err := strconv.ParseInt(s, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return errors.New("unsupported field type " + field.Type().String())
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package eventual
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBind(t *testing.T) {
+	type config struct {
+		Name     string        `eventual:"name"`
+		Enabled  bool          `eventual:"enabled"`
+		Workers  int           `eventual:"workers,default=4"`
+		Ratio    float64       `eventual:"ratio"`
+		Timeout  time.Duration `eventual:"timeout,default=5s"`
+		Addr     net.IP        `eventual:"addr"`
+		Untagged string
+	}
+
+	m := NewMap[string, string]()
+	m.Set("name", "svc")
+	m.Set("ratio", "0.5")
+	m.Set("addr", "10.0.0.1")
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.Set("enabled", "true")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var cfg config
+	require.NoError(t, Bind(ctx, &cfg, m, BindOptions{}))
+	require.Equal(t, config{
+		Name:    "svc",
+		Enabled: true,
+		Workers: 4,
+		Ratio:   0.5,
+		Timeout: 5 * time.Second,
+		Addr:    net.ParseIP("10.0.0.1"),
+	}, cfg)
+}
+
+func TestBindErrors(t *testing.T) {
+	type config struct {
+		Missing string `eventual:"missing"`
+		Count   int    `eventual:"count"`
+		Name    string `cfg:"name"`
+	}
+
+	m := NewMap[string, string]()
+	m.Set("count", "lots")
+	m.Set("name", "svc")
+
+	var cfg config
+	err := Bind(DontWait, &cfg, m, BindOptions{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Missing")
+	require.Contains(t, err.Error(), "Count")
+
+	require.Error(t, Bind(DontWait, cfg, m, BindOptions{}), "non-pointer should be rejected")
+
+	m.Set("count", "3")
+	require.NoError(t, Bind(DontWait, &cfg, m, BindOptions{Tag: "cfg", IgnoreMissing: true}))
+	require.Equal(t, "svc", cfg.Name)
+}
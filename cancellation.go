@@ -0,0 +1,77 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrCancelled is wrapped by the cause of contexts created by Cancellation.AsContext when the
+// Cancellation is cancelled.
+var ErrCancelled = errors.New("eventual: cancelled")
+
+// Cancellation is a cooperative cancellation token: a one-shot eventual value that's set with the
+// reason for cancelling, for propagating "abort this workflow, with a reason" across components.
+type Cancellation interface {
+	// Cancel cancels with the given reason. Only the first call has an effect; it reports whether
+	// it was the one to cancel.
+	Cancel(reason string) bool
+
+	// Reason returns the reason for cancelling and true, or false if not cancelled yet.
+	Reason() (string, bool)
+
+	// Done returns a channel that's closed once cancelled.
+	Done() <-chan struct{}
+
+	// AsContext returns a context derived from parent that's also cancelled once the Cancellation
+	// is, with a cause wrapping ErrCancelled and the reason (see context.Cause). Callers must call
+	// the returned cancel func to release resources once the context is no longer needed.
+	AsContext(parent context.Context) (context.Context, context.CancelFunc)
+}
+
+// NewCancellation creates a new Cancellation.
+func NewCancellation() Cancellation {
+	return &cancellation{
+		// The reason must never expire, whatever the defaults are
+		reason: newValueExact[string](nil),
+		done:   make(chan struct{}),
+	}
+}
+
+type cancellation struct {
+	reason *value[string]
+	once   sync.Once
+	done   chan struct{}
+}
+
+func (c *cancellation) Cancel(reason string) bool {
+	cancelled := false
+	c.once.Do(func() {
+		c.reason.Set(reason)
+		close(c.done)
+		cancelled = true
+	})
+	return cancelled
+}
+
+func (c *cancellation) Reason() (string, bool) {
+	return c.reason.Peek()
+}
+
+func (c *cancellation) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *cancellation) AsContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	go func() {
+		select {
+		case <-c.done:
+			reason, _ := c.Reason()
+			cancel(fmt.Errorf("%w: %v", ErrCancelled, reason))
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
@@ -0,0 +1,60 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancellation(t *testing.T) {
+	c := NewCancellation()
+	_, cancelled := c.Reason()
+	require.False(t, cancelled)
+	select {
+	case <-c.Done():
+		t.Fatal("shouldn't be done before Cancel")
+	default:
+	}
+
+	ctx, cancel := c.AsContext(context.Background())
+	defer cancel()
+
+	require.True(t, c.Cancel("upstream failed"))
+	require.False(t, c.Cancel("too late"), "only the first Cancel should count")
+	<-c.Done()
+	reason, cancelled := c.Reason()
+	require.True(t, cancelled)
+	require.Equal(t, "upstream failed", reason)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context should be cancelled")
+	}
+	require.Equal(t, context.Canceled, ctx.Err())
+	require.True(t, errors.Is(context.Cause(ctx), ErrCancelled))
+	require.EqualError(t, context.Cause(ctx), "eventual: cancelled: upstream failed")
+}
+
+func TestCancellationContextReleased(t *testing.T) {
+	c := NewCancellation()
+	ctx, cancel := c.AsContext(context.Background())
+	cancel()
+	<-ctx.Done()
+	require.Equal(t, context.Canceled, context.Cause(ctx))
+}
+
+func TestCancellationIgnoresDefaults(t *testing.T) {
+	t.Cleanup(func() { SetDefaults() })
+	SetDefaults(WithMaxAgePolicy(time.Millisecond))
+	c := NewCancellation()
+	require.True(t, c.Cancel("first"))
+	time.Sleep(5 * time.Millisecond)
+	require.False(t, c.Cancel("second"), "reason shouldn't expire")
+	reason, cancelled := c.Reason()
+	require.True(t, cancelled)
+	require.Equal(t, "first", reason)
+}
@@ -0,0 +1,90 @@
+package eventual
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Clock is the source of time for Values and Maps. Injecting a fake Clock with WithClock lets tests
+// control expiration and scheduling deterministically.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// AfterFunc calls f in its own goroutine once d has elapsed, unless the returned Timer is
+	// stopped first.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a scheduled call created by Clock.AfterFunc.
+type Timer interface {
+	// Stop prevents the call from happening, returning false if it has already happened or been
+	// stopped.
+	Stop() bool
+}
+
+// SystemClock is the Clock used by default. It's backed by the time package.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// WithClock configures the Clock that's used to tell whether values have expired and to schedule
+// future work. Defaults to SystemClock.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// withDeadline is like context.WithDeadline, but the deadline is told by clock.
+func withDeadline(ctx context.Context, clock Clock, deadline time.Time) (context.Context, context.CancelFunc) {
+	if clock == SystemClock {
+		return context.WithDeadline(ctx, deadline)
+	}
+	inner, cancel := context.WithCancel(ctx)
+	c := &clockDeadlineCtx{Context: inner, deadline: deadline}
+	expire := func() {
+		c.expired.Store(true)
+		cancel()
+	}
+	if !deadline.After(clock.Now()) {
+		expire()
+		return c, cancel
+	}
+	timer := clock.AfterFunc(deadline.Sub(clock.Now()), expire)
+	return c, func() {
+		timer.Stop()
+		cancel()
+	}
+}
+
+// clockDeadlineCtx is a context whose deadline is enforced by a Clock (see withDeadline).
+type clockDeadlineCtx struct {
+	context.Context
+	deadline time.Time
+	expired  atomic.Bool
+}
+
+func (c *clockDeadlineCtx) Deadline() (time.Time, bool) {
+	if parent, ok := c.Context.Deadline(); ok && parent.Before(c.deadline) {
+		return parent, true
+	}
+	return c.deadline, true
+}
+
+func (c *clockDeadlineCtx) Err() error {
+	err := c.Context.Err()
+	if err != nil && c.expired.Load() {
+		return context.DeadlineExceeded
+	}
+	return err
+}
@@ -0,0 +1,157 @@
+package eventual
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a Clock whose time only moves when advanced. Scheduled funcs run synchronously
+// during Advance.
+type fakeClock struct {
+	mx     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock   *fakeClock
+	at      time.Time
+	f       func()
+	stopped bool
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	t := &fakeTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mx.Lock()
+	c.now = c.now.Add(d)
+	var due []*fakeTimer
+	remaining := c.timers[:0]
+	for _, t := range c.timers {
+		if t.stopped {
+			continue
+		}
+		if !t.at.After(c.now) {
+			t.stopped = true
+			due = append(due, t)
+		} else {
+			remaining = append(remaining, t)
+		}
+	}
+	c.timers = remaining
+	c.mx.Unlock()
+
+	for _, t := range due {
+		t.f()
+	}
+}
+
+// pending returns the number of scheduled funcs that haven't run or been stopped yet.
+func (c *fakeClock) pending() int {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	count := 0
+	for _, t := range c.timers {
+		if !t.stopped {
+			count++
+		}
+	}
+	return count
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mx.Lock()
+	defer t.clock.mx.Unlock()
+	wasActive := !t.stopped
+	t.stopped = true
+	return wasActive
+}
+
+func TestClock(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	v.SetExpiring("a", clock.Now().Add(time.Minute))
+
+	clock.Advance(59 * time.Second)
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "a", r)
+
+	clock.Advance(time.Second)
+	_, err = v.Get(DontWait)
+	require.Error(t, err, "value should expire according to the injected clock")
+}
+
+func TestScheduleSet(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+
+	v.ScheduleSet("scheduled", clock.Now().Add(time.Minute))
+	cancel := v.ScheduleSet("cancelled", clock.Now().Add(2*time.Minute))
+
+	clock.Advance(30 * time.Second)
+	_, err := v.Get(DontWait)
+	require.Error(t, err)
+
+	clock.Advance(30 * time.Second)
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "scheduled", r)
+
+	cancel()
+	clock.Advance(time.Hour)
+	r, err = v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "scheduled", r, "cancelled set shouldn't happen")
+}
+
+func TestScheduleSetSystemClock(t *testing.T) {
+	v := NewValue[int]()
+	v.ScheduleSet(1, time.Now().Add(10*time.Millisecond))
+	cancel := v.ScheduleSet(2, time.Now().Add(20*time.Millisecond))
+	cancel()
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), time.Second)
+	defer cancelCtx()
+	r, err := v.Get(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, r)
+	time.Sleep(30 * time.Millisecond)
+	r, _ = v.Get(DontWait)
+	require.Equal(t, 1, r)
+}
+
+func TestSetExpiringIn(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	v.SetExpiringIn("a", time.Minute)
+	expiration, ok := v.Expiration()
+	require.True(t, ok)
+	require.Equal(t, clock.Now().Add(time.Minute), expiration)
+
+	m := NewMap[string, string](WithClock(clock))
+	m.SetExpiringIn("a", "A", time.Minute)
+	clock.Advance(time.Minute)
+	_, err := m.Get(DontWait, "a")
+	require.Error(t, err, "value should expire according to the injected clock")
+}
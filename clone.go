@@ -0,0 +1,84 @@
+package eventual
+
+func (v *value[V]) Clone() Value[V] {
+	return v.clone()
+}
+
+// clone returns a copy of the value's configuration and current value, without its waiters,
+// subscribers or update hook.
+func (v *value[V]) clone() *value[V] {
+	v.m.Lock()
+	defer v.m.Unlock()
+	result := &value[V]{
+		defaultValue: v.defaultValue,
+		defaultFn:    v.defaultFn,
+		hasDefault:   v.hasDefault,
+		sensitive:    v.sensitive,
+		weigher:      v.weigher,
+		misuse:       v.misuse,
+		equal:        v.equal,
+		clock:        v.clock,
+		grace:        v.grace,
+		interval:     v.interval,
+		diagnose:     v.diagnose,
+		maxAge:       v.maxAge,
+		fallbacks:    v.fallbacks,
+		quorum:       v.quorum.clone(),
+		history:      append([]Entry[V](nil), v.history...),
+		historySize:  v.historySize,
+
+		defaultOnImmediate: v.defaultOnImmediate,
+		defaultReporter:    v.defaultReporter,
+		jsonExpiration:     v.jsonExpiration,
+		visiblePayloads:    v.visiblePayloads,
+		audit:              v.audit,
+	}
+	s := v.snapshot()
+	if s != nil && s.pinned {
+		// Pins belong to the original, the clone expires normally
+		unpinned := *s
+		unpinned.pinned = false
+		s = &unpinned
+	}
+	if s.valid(v.clock.Now()) {
+		result.v = s.v
+		result.expiration = s.expiration
+		result.softExpiration = s.softExpiration
+		result.provenance = v.provenance
+		result.weight = v.weight
+		result.set = true
+		result.everSet = true
+		// Snapshots are immutable, so they can be shared
+		result.published.Store(s)
+		result.scheduleLapse()
+	}
+	return result
+}
+
+func (m *emap[K, V]) Clone() Map[K, V] {
+	result := newMap[K, V](m.opts)
+	if m.defaults != nil {
+		result.defaults = make(map[K]V, len(m.defaults))
+		for key, defaultValue := range m.defaults {
+			result.defaults[key] = defaultValue
+		}
+	}
+	// Both Maps have the same shards and shard function, so every key goes to the same shard
+	for i, s := range m.shards {
+		s.mx.Lock()
+		dst := result.shards[i]
+		for key, v := range s.m {
+			key, clone := key, v.clone()
+			clone.onUpdate = func(u Update[V]) {
+				result.publish(key, u)
+			}
+			clone.watched = result.watched
+			clone.m.Lock()
+			clone.scheduleLapse()
+			clone.m.Unlock()
+			dst.m[key] = clone
+		}
+		s.mx.Unlock()
+	}
+	return result
+}
@@ -0,0 +1,92 @@
+package eventual
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueClone(t *testing.T) {
+	clock := newFakeClock()
+	v := WithDefault[string]("default", WithClock(clock))
+	expiration := clock.Now().Add(time.Second)
+	v.SetExpiring("a", expiration)
+
+	clone := v.Clone()
+	r, err := clone.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "a", r)
+	cloneExpiration, ok := clone.Expiration()
+	require.True(t, ok)
+	require.Equal(t, expiration, cloneExpiration)
+
+	clone.Set("b")
+	r, err = v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "a", r, "changing the clone shouldn't affect the original")
+
+	clone.Reset()
+	r, err = clone.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "default", r, "the default should be cloned")
+
+	clock.Advance(time.Second)
+	_, ok = v.Clone().Expiration()
+	require.False(t, ok, "expired values shouldn't be cloned")
+}
+
+func TestValueClonePinned(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	v.SetExpiring("a", clock.Now().Add(time.Second))
+	_, unpin, err := v.Pin()
+	require.NoError(t, err)
+	defer unpin()
+
+	clone := v.Clone()
+	expired := clone.NotifyExpiry()
+	clock.Advance(time.Second)
+	require.True(t, clone.IsExpired(), "the original's pin shouldn't keep the clone alive")
+	select {
+	case <-expired:
+	case <-time.After(time.Second):
+		t.Fatal("clone's expiry should be notified")
+	}
+	require.False(t, v.IsExpired(), "the original should still be pinned")
+}
+
+func TestValueCloneWithoutWaiters(t *testing.T) {
+	v := NewValue[string]()
+	go v.Get(context.Background())
+	require.Eventually(t, func() bool { return v.Waiters() == 1 }, time.Second, time.Millisecond)
+	clone := v.Clone()
+	require.Zero(t, clone.Waiters())
+	v.Set("a")
+}
+
+func TestMapClone(t *testing.T) {
+	m := NewMapWithDefaults[string, int](map[string]int{"c": 3})
+	m.Set("a", 1)
+	m.Set("b", 2)
+
+	clone := m.Clone()
+	clone.Set("a", 10)
+	clone.Reset("b")
+
+	a, err := m.Get(DontWait, "a")
+	require.NoError(t, err)
+	require.Equal(t, 1, a, "changing the clone shouldn't affect the original")
+	b, err := m.Get(DontWait, "b")
+	require.NoError(t, err)
+	require.Equal(t, 2, b)
+
+	a, err = clone.Get(DontWait, "a")
+	require.NoError(t, err)
+	require.Equal(t, 10, a)
+	c, err := clone.Get(DontWait, "c")
+	require.NoError(t, err)
+	require.Equal(t, 3, c, "defaults should be cloned")
+	require.Equal(t, 3, clone.Stats().Keys)
+}
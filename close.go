@@ -0,0 +1,64 @@
+package eventual
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrClosed is returned when reading from a Value that has been closed, and reported when writing
+// to it.
+var ErrClosed = errors.New("eventual: value is closed")
+
+func (v *value[V]) Close() {
+	v.m.Lock()
+	defer v.m.Unlock()
+	if v.closed.Load() {
+		return
+	}
+	v.closed.Store(true)
+	v.failure.Store(&failure{err: ErrClosed})
+	v.endExpiry()
+	v.stopLapse()
+	v.pins = 0
+	if v.sensitive {
+		zeroBytes(v.v)
+	}
+	v.v = v.zeroValue
+	v.expiration = time.Time{}
+	v.softExpiration = time.Time{}
+	v.provenance = Provenance{}
+	v.weight = 0
+	v.set = false
+	v.published.Store((*snapshot[V])(nil))
+	for w := range v.waiters {
+		w.fail(ErrClosed)
+	}
+	v.waiters = nil
+	for sub := range v.subscribers {
+		close(sub.ch)
+	}
+	v.subscribers = nil
+	v.propagate()
+}
+
+func (m *emap[K, V]) Close() {
+	m.closeOnce.Do(func() {
+		m.closedValue = newValueExact[V](m.opts)
+		m.closedValue.Close()
+		// From here on, getValue hands out closedValue instead of tracking new keys
+		m.closed.Store(true)
+		for _, s := range m.shards {
+			s.mx.Lock()
+			for _, v := range s.m {
+				v.Close()
+			}
+			s.mx.Unlock()
+		}
+		close(m.done)
+	})
+}
+
+func (m *loadingMap[K, V]) Close() {
+	m.Stop()
+	m.emap.Close()
+}
@@ -0,0 +1,124 @@
+package eventual
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapClose(t *testing.T) {
+	var misuses int32
+	m := NewMap[string, string](WithMisuseHandler(func(err error) {
+		require.Equal(t, ErrClosed, err)
+		atomic.AddInt32(&misuses, 1)
+	}))
+	m.Set("a", "A")
+
+	errs := make(chan error, 2)
+	go func() {
+		_, err := m.Get(context.Background(), "b")
+		errs <- err
+	}()
+	go func() {
+		errs <- m.WaitCount(context.Background(), 5)
+	}()
+	require.Eventually(t, func() bool {
+		return m.(*emap[string, string]).getValue("b").Waiters() == 1
+	}, time.Second, time.Millisecond)
+
+	m.Close()
+	m.Close()
+	require.Equal(t, ErrClosed, <-errs, "blocked callers should be released")
+	require.Equal(t, ErrClosed, <-errs, "blocked callers should be released")
+
+	_, err := m.Get(DontWait, "a")
+	require.Equal(t, ErrClosed, err, "existing keys should be dropped")
+	_, err = m.Get(context.Background(), "c")
+	require.Equal(t, ErrClosed, err, "unknown keys should fail fast")
+	_, err = m.GetOrSetExpiring("c", time.Now().Add(time.Minute), func() (string, error) {
+		t.Fatal("getter shouldn't be called after Close")
+		return "", nil
+	})
+	require.Equal(t, ErrClosed, err)
+
+	m.Set("a", "A2")
+	m.Reset("c")
+	require.EqualValues(t, 2, atomic.LoadInt32(&misuses))
+	require.Equal(t, 2, m.Stats().Keys, "no new keys should be tracked after Close")
+
+	for range m.Events(context.Background()) {
+		t.Fatal("events should end after Close")
+	}
+}
+
+func TestMapCloseEndsUpdates(t *testing.T) {
+	m := NewMap[string, int]()
+	m.Set("a", 1)
+	v := m.(*emap[string, int]).getValue("a")
+
+	done := make(chan []int)
+	go func() {
+		var seen []int
+		for i := range v.Updates(context.Background()) {
+			seen = append(seen, i)
+		}
+		done <- seen
+	}()
+	require.Eventually(t, func() bool {
+		v.m.Lock()
+		defer v.m.Unlock()
+		return len(v.subscribers) == 1
+	}, time.Second, time.Millisecond)
+
+	m.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Updates should end after Close")
+	}
+}
+
+func TestLoadingMapClose(t *testing.T) {
+	clock := newFakeClock()
+	var loads int32
+	m := NewLoadingMap[string, string](func(ctx context.Context, key string) (string, error) {
+		atomic.AddInt32(&loads, 1)
+		return key, nil
+	}, LoadingConfig[string]{TTL: time.Minute, RefreshAhead: 10 * time.Second}, WithClock(clock))
+
+	_, err := m.Load(context.Background(), "a")
+	require.NoError(t, err)
+	m.Close()
+
+	clock.Advance(time.Minute)
+	require.EqualValues(t, 1, atomic.LoadInt32(&loads), "refreshes should stop on Close")
+	_, err = m.Load(context.Background(), "b")
+	require.Equal(t, ErrClosed, err)
+}
+
+func TestValueClose(t *testing.T) {
+	v := NewValue[string]()
+	errs := make(chan error)
+	go func() {
+		_, err := v.Get(context.Background())
+		errs <- err
+	}()
+	require.Eventually(t, func() bool { return v.Waiters() == 1 }, time.Second, time.Millisecond)
+
+	v.Close()
+	require.Equal(t, ErrClosed, <-errs)
+	_, err := v.Get(context.Background())
+	require.Equal(t, ErrClosed, err, "Gets after Close should fail fast")
+
+	require.Equal(t, ErrClosed, v.TrySet("a"))
+	v.Set("a")
+	_, err = v.Get(DontWait)
+	require.Equal(t, ErrClosed, err, "Sets after Close should be no-ops")
+	for range v.Updates(context.Background()) {
+		t.Fatal("Updates should end immediately after Close")
+	}
+	v.Close()
+}
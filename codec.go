@@ -0,0 +1,99 @@
+package eventual
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrCiphertextTooShort is returned by an encrypting Codec when asked to decode data that can't
+// possibly have been produced by it.
+var ErrCiphertextTooShort = errors.New("eventual: ciphertext too short")
+
+// Codec converts values to and from bytes. It's used wherever values leave the process, such as
+// Map.Export and Map.Import, so that payloads that aren't well served by JSON (e.g. protobufs) can
+// be plugged in once with WithCodec. JSONCodec and GobCodec are provided.
+type Codec[V any] interface {
+	Encode(value V) ([]byte, error)
+	Decode(data []byte) (V, error)
+}
+
+// JSONCodec is a Codec that uses encoding/json.
+type JSONCodec[V any] struct{}
+
+func (JSONCodec[V]) Encode(value V) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (JSONCodec[V]) Decode(data []byte) (V, error) {
+	var value V
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+// GobCodec is a Codec that uses encoding/gob. Each value is encoded as a self-contained gob stream,
+// including its type information.
+type GobCodec[V any] struct{}
+
+func (GobCodec[V]) Encode(value V) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(value)
+	return buf.Bytes(), err
+}
+
+func (GobCodec[V]) Decode(data []byte) (V, error) {
+	var value V
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+	return value, err
+}
+
+// NewAESGCMCodec wraps inner in a Codec that seals everything inner encodes with AES-GCM, so that
+// values written to disk are encrypted at rest. The key must be 16, 24 or 32 bytes long, selecting
+// AES-128, AES-192 or AES-256. Each encoding uses a fresh random nonce, which is prepended to the
+// ciphertext.
+func NewAESGCMCodec[V any](key []byte, inner Codec[V]) (Codec[V], error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &aesGCMCodec[V]{aead: aead, inner: inner}, nil
+}
+
+type aesGCMCodec[V any] struct {
+	aead  cipher.AEAD
+	inner Codec[V]
+}
+
+func (c *aesGCMCodec[V]) Encode(value V) ([]byte, error) {
+	plaintext, err := c.inner.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plaintext)+c.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (c *aesGCMCodec[V]) Decode(data []byte) (V, error) {
+	var zero V
+	if len(data) < c.aead.NonceSize() {
+		return zero, ErrCiphertextTooShort
+	}
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return zero, err
+	}
+	return c.inner.Decode(plaintext)
+}
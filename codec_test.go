@@ -0,0 +1,60 @@
+package eventual
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAESGCMCodec(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	codec, err := NewAESGCMCodec[string](key, JSONCodec[string]{})
+	require.NoError(t, err)
+
+	data, err := codec.Encode("top secret")
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(data, []byte("top secret")), "payload should be encrypted")
+
+	decoded, err := codec.Decode(data)
+	require.NoError(t, err)
+	require.Equal(t, "top secret", decoded)
+
+	data[len(data)-1] ^= 0xff
+	_, err = codec.Decode(data)
+	require.Error(t, err, "tampered ciphertext should be rejected")
+
+	_, err = codec.Decode([]byte{1, 2})
+	require.Equal(t, ErrCiphertextTooShort, err)
+
+	otherCodec, err := NewAESGCMCodec[string](bytes.Repeat([]byte{8}, 32), JSONCodec[string]{})
+	require.NoError(t, err)
+	data, _ = codec.Encode("top secret")
+	_, err = otherCodec.Decode(data)
+	require.Error(t, err, "decoding with the wrong key should fail")
+
+	_, err = NewAESGCMCodec[string]([]byte("short"), JSONCodec[string]{})
+	require.Error(t, err)
+}
+
+func TestCodecs(t *testing.T) {
+	type payload struct {
+		Name  string
+		Count int
+	}
+	for name, codec := range map[string]Codec[payload]{
+		"json": JSONCodec[payload]{},
+		"gob":  GobCodec[payload]{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := codec.Encode(payload{"a", 1})
+			require.NoError(t, err)
+			decoded, err := codec.Decode(data)
+			require.NoError(t, err)
+			require.Equal(t, payload{"a", 1}, decoded)
+
+			_, err = codec.Decode([]byte("garbage"))
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package eventual
+
+import (
+	"context"
+)
+
+type computed[V comparable] struct {
+	v   V
+	err error
+}
+
+func (v *value[V]) GetOrCompute(ctx context.Context, compute func(context.Context) (V, error)) (V, error) {
+	if s := v.snapshot(); s.valid(v.clock.Now()) {
+		return s.v, nil
+	}
+	if err := v.failed(); err != nil {
+		return v.zeroValue, err
+	}
+
+	w, _v, ok := v.waiter()
+	if ok {
+		return _v, nil
+	}
+	defer v.removeWaiter(w)
+
+	computeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	graceUp := make(chan struct{})
+	if v.grace > 0 {
+		timer := v.clock.AfterFunc(v.grace, func() { close(graceUp) })
+		defer timer.Stop()
+	} else {
+		close(graceUp)
+	}
+	results := make(chan computed[V], 1)
+	for {
+		select {
+		case o := <-w.ch:
+			return o.v, o.err
+		case <-graceUp:
+			// Only start computing once
+			graceUp = nil
+			go func() {
+				v.m.Lock()
+				v.beginLoad()
+				v.m.Unlock()
+				i, err := compute(computeCtx)
+				v.m.Lock()
+				v.endLoad()
+				v.m.Unlock()
+				results <- computed[V]{i, err}
+			}()
+		case r := <-results:
+			return v.storeComputed(r.v, r.err)
+		case <-ctx.Done():
+			return v.contextDone(ctx, w.since)
+		}
+	}
+}
+
+// storeComputed stores the result of GetOrCompute's compute func, unless a value was set in the
+// meantime, in which case that value is returned instead.
+func (v *value[V]) storeComputed(i V, err error) (V, error) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	v.recordError(err)
+	if err != nil {
+		return v.zeroValue, err
+	}
+	if s := v.snapshot(); s.valid(v.clock.Now()) {
+		return s.v, nil
+	}
+	if writeErr := v.writable(); writeErr != nil {
+		return v.zeroValue, writeErr
+	}
+	v.doSetExpiring(i, v.clock.Now().Add(tenYears))
+	return i, nil
+}
@@ -0,0 +1,84 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOrComputePrefersSet(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock), WithComputeGrace(time.Second))
+	result := make(chan string)
+	go func() {
+		r, err := v.GetOrCompute(context.Background(), func(ctx context.Context) (string, error) {
+			t.Error("compute shouldn't run before the grace period is up")
+			return "", nil
+		})
+		require.NoError(t, err)
+		result <- r
+	}()
+	require.Eventually(t, func() bool { return v.Waiters() == 1 }, time.Second, time.Millisecond)
+	v.Set("pushed")
+	require.Equal(t, "pushed", <-result)
+	require.Zero(t, v.Waiters())
+}
+
+func TestGetOrComputeFallsBackToCompute(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock), WithComputeGrace(time.Second))
+	result := make(chan string)
+	go func() {
+		r, err := v.GetOrCompute(context.Background(), func(ctx context.Context) (string, error) {
+			return "pulled", nil
+		})
+		require.NoError(t, err)
+		result <- r
+	}()
+	require.Eventually(t, func() bool { return v.Waiters() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Second)
+	require.Equal(t, "pulled", <-result)
+
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "pulled", r, "computed value should be stored")
+}
+
+func TestGetOrComputeCancelsCompute(t *testing.T) {
+	v := NewValue[string]()
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-started
+		v.Set("pushed")
+	}()
+	r, err := v.GetOrCompute(context.Background(), func(ctx context.Context) (string, error) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return "pulled", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "pushed", r, "a Set should win over a slower compute")
+	<-cancelled
+
+	r, err = v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "pushed", r)
+}
+
+func TestGetOrComputeError(t *testing.T) {
+	v := NewValue[string]()
+	fail := errors.New("fail")
+	_, err := v.GetOrCompute(context.Background(), func(ctx context.Context) (string, error) {
+		return "", fail
+	})
+	require.Equal(t, fail, err)
+	lastErr, _ := v.LastError()
+	require.Equal(t, fail, lastErr)
+	require.False(t, v.IsSet())
+}
@@ -0,0 +1,145 @@
+package eventual
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// LockableMap is a Map that can take part in ReadConsistent. Every Map is a LockableMap.
+type LockableMap interface {
+	// lockID orders Maps, so that ReadConsistent always locks them in the same order.
+	lockID() uint64
+
+	// lockAll locks every shard of the Map, and then every Value in it, which keeps them from
+	// changing until unlockAll is called.
+	lockAll()
+	unlockAll()
+
+	// viewLocked returns a ReadView of the Map. Must be called while locked.
+	viewLocked() ReadView
+}
+
+// ReadView is a point-in-time, read-only copy of the contents of a Map, as passed to the function
+// given to ReadConsistent. Keys and values have the Map's key and value types, see ViewGet for a
+// typed alternative to Get.
+type ReadView interface {
+	// Get returns the value at key and true if key held an unexpired value when the view was taken.
+	Get(key any) (any, bool)
+
+	// Keys returns every key that held an unexpired value when the view was taken, in no
+	// particular order.
+	Keys() []any
+
+	// Len returns the number of keys that held an unexpired value when the view was taken.
+	Len() int
+}
+
+// mapIDs hands out the lockIDs of Maps.
+var mapIDs atomic.Uint64
+
+// ReadConsistent calls fn with a ReadView of each of the given Maps, in the same order, all taken at
+// the same point in time, so that invariants that span several Maps can be checked without
+// observing some of them before and some after a concurrent write. The Maps are locked only while
+// the views are taken, so fn is free to use them. ReadConsistent returns fn's error.
+func ReadConsistent(fn func(views ...ReadView) error, maps ...LockableMap) error {
+	// Lock every Map once, in a global order, so that concurrent calls can't deadlock
+	ordered := make([]LockableMap, 0, len(maps))
+	seen := make(map[uint64]bool, len(maps))
+	for _, m := range maps {
+		if !seen[m.lockID()] {
+			seen[m.lockID()] = true
+			ordered = append(ordered, m)
+		}
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].lockID() < ordered[j].lockID()
+	})
+
+	for _, m := range ordered {
+		m.lockAll()
+	}
+	views := make([]ReadView, len(maps))
+	for i, m := range maps {
+		views[i] = m.viewLocked()
+	}
+	for i := len(ordered) - 1; i >= 0; i-- {
+		ordered[i].unlockAll()
+	}
+	return fn(views...)
+}
+
+// ViewGet is like ReadView.Get, but typed. It returns false if key isn't a K, or if the value at key
+// isn't a V, as happens when V doesn't match the value type of the Map the view was taken of.
+func ViewGet[K comparable, V comparable](view ReadView, key K) (V, bool) {
+	result, ok := view.Get(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	typed, ok := result.(V)
+	return typed, ok
+}
+
+type mapView[K comparable, V comparable] struct {
+	entries map[K]V
+}
+
+func (v *mapView[K, V]) Get(key any) (any, bool) {
+	k, ok := key.(K)
+	if !ok {
+		return nil, false
+	}
+	result, found := v.entries[k]
+	return result, found
+}
+
+func (v *mapView[K, V]) Keys() []any {
+	keys := make([]any, 0, len(v.entries))
+	for key := range v.entries {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (v *mapView[K, V]) Len() int {
+	return len(v.entries)
+}
+
+func (m *emap[K, V]) lockID() uint64 {
+	return m.id
+}
+
+func (m *emap[K, V]) lockAll() {
+	for _, s := range m.shards {
+		s.mx.Lock()
+	}
+	for _, s := range m.shards {
+		for _, v := range s.m {
+			v.m.Lock()
+		}
+	}
+}
+
+func (m *emap[K, V]) unlockAll() {
+	for _, s := range m.shards {
+		for _, v := range s.m {
+			v.m.Unlock()
+		}
+	}
+	for _, s := range m.shards {
+		s.mx.Unlock()
+	}
+}
+
+func (m *emap[K, V]) viewLocked() ReadView {
+	now := m.clock.Now()
+	entries := make(map[K]V)
+	for _, s := range m.shards {
+		for key, v := range s.m {
+			if snap := v.snapshot(); snap.valid(now) {
+				entries[key] = snap.v
+			}
+		}
+	}
+	return &mapView[K, V]{entries}
+}
@@ -0,0 +1,84 @@
+package eventual
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadConsistent(t *testing.T) {
+	clock := newFakeClock()
+	a := NewMap[string, int](WithClock(clock))
+	b := NewMap[int, string](WithClock(clock))
+	a.Set("x", 1)
+	a.SetExpiring("expired", 2, clock.Now().Add(-time.Second))
+	b.Set(1, "one")
+
+	fail := errors.New("fail")
+	err := ReadConsistent(func(views ...ReadView) error {
+		require.Len(t, views, 3)
+		require.Equal(t, 1, views[0].Len(), "expired keys shouldn't be included")
+		r, ok := ViewGet[string, int](views[0], "x")
+		require.True(t, ok)
+		require.Equal(t, 1, r)
+		_, ok = ViewGet[string, string](views[0], "x")
+		require.False(t, ok, "value of the wrong type shouldn't be found")
+		_, ok = views[0].Get(1)
+		require.False(t, ok, "key of the wrong type shouldn't be found")
+		require.Equal(t, []any{1}, views[1].Keys())
+		require.Equal(t, views[0].Keys(), views[2].Keys(), "same Map should get the same view")
+
+		a.Set("y", 2)
+		require.Equal(t, 1, views[0].Len(), "view shouldn't change after it was taken")
+		return fail
+	}, a, b, a)
+	require.True(t, errors.Is(err, fail))
+}
+
+func TestReadConsistentNoTornReads(t *testing.T) {
+	a := NewMap[string, int]()
+	b := NewMap[string, int]()
+	a.Set("x", 0)
+	b.Set("x", 0)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			a.Set("x", i)
+			b.Set("x", i)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// Readers passing the Maps in the opposite order mustn't deadlock
+		for i := 0; i < 1000; i++ {
+			ReadConsistent(func(...ReadView) error { return nil }, b, a)
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		ReadConsistent(func(views ...ReadView) error {
+			ra, _ := ViewGet[string, int](views[0], "x")
+			rb, _ := ViewGet[string, int](views[1], "x")
+			if ra != rb && ra != rb+1 {
+				t.Fatalf("torn read: a=%d, b=%d", ra, rb)
+			}
+			return nil
+		}, a, b)
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,124 @@
+package eventual
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TimeoutError is returned by Get when no value is available in time and the Value was created
+// with WithTimeoutDiagnostics. It describes the Value's state at the time, for example "timed out,
+// but a refresh started 45s ago and hasn't returned". It wraps the error that Get would have
+// returned otherwise, so errors.Is works as usual.
+type TimeoutError struct {
+	// EverSet is whether the Value had ever been set.
+	EverSet bool
+
+	// ExpiredAt is when the Value's last value expired, or the zero time if it isn't expired.
+	ExpiredAt time.Time
+
+	// LoadingSince is when the Value started loading, or the zero time if no getter, refresh or
+	// loader is running. Overlapping loads count as one.
+	LoadingSince time.Time
+
+	// Now is when the diagnosis was made, according to the configured Clock.
+	Now time.Time
+
+	err error
+}
+
+func (e *TimeoutError) Error() string {
+	details := make([]string, 0, 3)
+	if !e.EverSet {
+		details = append(details, "never set")
+	}
+	if !e.ExpiredAt.IsZero() {
+		details = append(details, fmt.Sprintf("expired %v ago", e.Now.Sub(e.ExpiredAt)))
+	}
+	if !e.LoadingSince.IsZero() {
+		details = append(details, fmt.Sprintf("load in flight for %v", e.Now.Sub(e.LoadingSince)))
+	} else {
+		details = append(details, "no load in flight")
+	}
+	return fmt.Sprintf("%v (%v)", e.err, strings.Join(details, ", "))
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.err
+}
+
+// diagnosed wraps err in a TimeoutError describing the value's current state.
+func (v *value[V]) diagnosed(err error) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+	result := &TimeoutError{
+		EverSet:      v.everSet,
+		LoadingSince: v.loadingSince,
+		Now:          v.clock.Now(),
+		err:          err,
+	}
+	if s := v.snapshot(); s != nil && !s.valid(result.Now) {
+		result.ExpiredAt = s.expiration
+	}
+	return result
+}
+
+// beginLoad records that a getter, refresh or loader started running outside of the mutex. Must be
+// called while holding the mutex.
+func (v *value[V]) beginLoad() {
+	if v.loads == 0 {
+		v.loadingSince = v.clock.Now()
+	}
+	v.loads++
+}
+
+// endLoad records that a load started with beginLoad has finished. Must be called while holding
+// the mutex.
+func (v *value[V]) endLoad() {
+	v.loads--
+	if v.loads == 0 {
+		v.loadingSince = time.Time{}
+	}
+}
+
+// defaultReporter throttles the calls to a handler configured with WithDefaultFallbackHandler.
+type defaultReporter struct {
+	name     string
+	interval time.Duration
+	handler  func(name string, waited time.Duration, suppressed int)
+
+	mx         sync.Mutex
+	last       time.Time
+	suppressed int
+}
+
+// report tells the handler about a Get that waited for waited before falling back to the default,
+// unless it was called less than interval ago.
+func (r *defaultReporter) report(now time.Time, waited time.Duration) {
+	r.mx.Lock()
+	if !r.last.IsZero() && now.Sub(r.last) < r.interval {
+		r.suppressed++
+		r.mx.Unlock()
+		return
+	}
+	r.last = now
+	suppressed := r.suppressed
+	r.suppressed = 0
+	r.mx.Unlock()
+	r.handler(r.name, waited, suppressed)
+}
+
+// fallBackToDefault returns the Value's default, reporting that a Get fell back to it after waiting
+// since the given time. A zero since means that the Get didn't wait.
+func (v *value[V]) fallBackToDefault(since time.Time) V {
+	if v.defaultReporter != nil {
+		now := v.clock.Now()
+		var waited time.Duration
+		if !since.IsZero() {
+			waited = now.Sub(since)
+		}
+		v.defaultReporter.report(now, waited)
+	}
+	return v.getDefault()
+}
@@ -0,0 +1,86 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutDiagnostics(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock), WithTimeoutDiagnostics())
+
+	_, err := v.Get(DontWait)
+	var timeoutErr *TimeoutError
+	require.True(t, errors.As(err, &timeoutErr))
+	require.False(t, timeoutErr.EverSet)
+	require.True(t, errors.Is(err, ErrNotSet))
+	require.True(t, errors.Is(err, context.Canceled))
+	require.EqualError(t, err, "eventual: value not set: context canceled (never set, no load in flight)")
+
+	v.SetExpiringSoft("a", clock.Now().Add(time.Second), clock.Now().Add(2*time.Second))
+	clock.Advance(time.Second)
+	release := make(chan struct{})
+	defer close(release)
+	_, err = v.GetOrSetSoftExpiring(clock.Now().Add(time.Minute), clock.Now().Add(time.Minute), func() (string, error) {
+		<-release
+		return "b", nil
+	})
+	require.NoError(t, err, "stale value should be served while refreshing")
+	clock.Advance(45 * time.Second)
+
+	_, err = v.Get(DontWait)
+	require.True(t, errors.As(err, &timeoutErr))
+	require.True(t, timeoutErr.EverSet)
+	require.True(t, errors.Is(err, ErrExpired))
+	require.EqualError(t, err, "eventual: value expired: context canceled (expired 44s ago, load in flight for 45s)")
+}
+
+func TestNoTimeoutDiagnostics(t *testing.T) {
+	v := NewValue[string]()
+	_, err := v.Get(DontWait)
+	var timeoutErr *TimeoutError
+	require.False(t, errors.As(err, &timeoutErr), "diagnostics should be opt-in")
+}
+
+func TestDefaultFallbackHandler(t *testing.T) {
+	clock := newFakeClock()
+	type report struct {
+		name       string
+		waited     time.Duration
+		suppressed int
+	}
+	var reports []report
+	opt := WithDefaultFallbackHandler("config", time.Minute, func(name string, waited time.Duration, suppressed int) {
+		reports = append(reports, report{name, waited, suppressed})
+	})
+	v := WithDefault("default", WithClock(clock), opt)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan string)
+	go func() {
+		r, _ := v.Get(ctx)
+		done <- r
+	}()
+	require.Eventually(t, func() bool { return v.Waiters() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(5 * time.Second)
+	cancel()
+	require.Equal(t, "default", <-done)
+	require.Equal(t, []report{{"config", 5 * time.Second, 0}}, reports)
+
+	m := NewMapWithDefaults(map[string]string{"a": "x", "b": "y"}, WithClock(clock), opt)
+	m.Get(DontWait, "a")
+	m.Get(DontWait, "b")
+	require.Len(t, reports, 1, "fallbacks should be throttled across Values sharing the option")
+
+	clock.Advance(time.Minute)
+	m.Get(DontWait, "a")
+	require.Equal(t, report{"config", 0, 2}, reports[1], "suppressed fallbacks should be counted")
+
+	v.Set("a")
+	v.Get(DontWait)
+	require.Len(t, reports, 2, "Gets that don't fall back shouldn't be reported")
+}
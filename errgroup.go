@@ -0,0 +1,23 @@
+package eventual
+
+// TaskGroup is the subset of golang.org/x/sync/errgroup.Group's API that SetFromFunc needs. An
+// *errgroup.Group satisfies it, without this package having to depend on x/sync.
+type TaskGroup interface {
+	Go(f func() error)
+}
+
+// SetFromFunc runs f as a task in group and sets value to its result. If f fails, value fails with
+// the error instead (see Value.SetError), so callers waiting for it don't hang, and the error is
+// returned to group as usual. This lets errgroup-based startup code feed eventual values without
+// wrapper boilerplate.
+func SetFromFunc[V comparable](group TaskGroup, value Value[V], f func() (V, error)) {
+	group.Go(func() error {
+		result, err := f()
+		if err != nil {
+			value.SetError(err)
+			return err
+		}
+		value.Set(result)
+		return nil
+	})
+}
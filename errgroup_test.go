@@ -0,0 +1,48 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testTaskGroup is a minimal stand-in for errgroup.Group.
+type testTaskGroup struct {
+	wg      sync.WaitGroup
+	errOnce sync.Once
+	err     error
+}
+
+func (g *testTaskGroup) Go(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := f(); err != nil {
+			g.errOnce.Do(func() { g.err = err })
+		}
+	}()
+}
+
+func (g *testTaskGroup) Wait() error {
+	g.wg.Wait()
+	return g.err
+}
+
+func TestSetFromFunc(t *testing.T) {
+	g := &testTaskGroup{}
+	config := NewValue[string]()
+	secrets := NewValue[string]()
+	fail := errors.New("vault unavailable")
+	SetFromFunc(g, config, func() (string, error) { return "config", nil })
+	SetFromFunc(g, secrets, func() (string, error) { return "", fail })
+
+	r, err := config.Get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "config", r)
+	_, err = secrets.Get(context.Background())
+	require.Equal(t, fail, err, "waiters should receive the task's error")
+	require.Equal(t, fail, g.Wait(), "the error should be returned to the group")
+}
@@ -0,0 +1,127 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotSet is wrapped by the error returned from Value.Get when the context expired before the
+	// value was set, or after it was Reset.
+	ErrNotSet = errors.New("eventual: value not set")
+
+	// ErrExpired is wrapped by the error returned from Value.Get when the context expired while the
+	// value was expired.
+	ErrExpired = errors.New("eventual: value expired")
+)
+
+// unavailableError explains why Get didn't return a value. It wraps both the reason and the
+// context's error.
+type unavailableError struct {
+	reason error
+	ctxErr error
+}
+
+func (e *unavailableError) Error() string {
+	return e.reason.Error() + ": " + e.ctxErr.Error()
+}
+
+func (e *unavailableError) Unwrap() []error {
+	return []error{e.reason, e.ctxErr}
+}
+
+// The common combinations are allocated up front so that Get with an expired context doesn't
+// allocate.
+var (
+	notSetCanceled  = &unavailableError{ErrNotSet, context.Canceled}
+	notSetDeadline  = &unavailableError{ErrNotSet, context.DeadlineExceeded}
+	expiredCanceled = &unavailableError{ErrExpired, context.Canceled}
+	expiredDeadline = &unavailableError{ErrExpired, context.DeadlineExceeded}
+)
+
+// unavailable returns the error for a Get whose context expired with ctxErr.
+func unavailable(expired bool, ctxErr error) error {
+	switch {
+	case !expired && ctxErr == context.Canceled:
+		return notSetCanceled
+	case !expired && ctxErr == context.DeadlineExceeded:
+		return notSetDeadline
+	case expired && ctxErr == context.Canceled:
+		return expiredCanceled
+	case expired && ctxErr == context.DeadlineExceeded:
+		return expiredDeadline
+	case expired:
+		return &unavailableError{ErrExpired, ctxErr}
+	default:
+		return &unavailableError{ErrNotSet, ctxErr}
+	}
+}
+
+// failure is an error that a value failed with.
+type failure struct {
+	err error
+	// expiration is when the value stops failing, or the zero time if it fails until it's Set or
+	// Reset.
+	expiration time.Time
+}
+
+// failed returns the error that the value failed with, or nil.
+func (v *value[V]) failed() error {
+	if f := v.failure.Load(); f != nil {
+		if f.expiration.IsZero() || f.expiration.After(v.clock.Now()) {
+			return f.err
+		}
+	}
+	return nil
+}
+
+func (v *value[V]) SetError(err error) {
+	v.m.Lock()
+	if writeErr := v.writable(); writeErr != nil {
+		v.m.Unlock()
+		v.reportMisuse(writeErr)
+		return
+	}
+	v.doSetError(err, time.Time{})
+	v.m.Unlock()
+}
+
+// doSetError makes the value fail with err until the given expiration, if any. Must be called
+// while holding the mutex.
+func (v *value[V]) doSetError(err error, expiration time.Time) {
+	old := v.previous()
+	v.endExpiry()
+	v.stopLapse()
+	v.pins = 0
+	if v.quorum != nil {
+		v.quorum.clear()
+	}
+	if v.sensitive {
+		zeroBytes(v.v)
+	}
+	v.v = v.zeroValue
+	v.expiration = time.Time{}
+	v.softExpiration = time.Time{}
+	v.provenance = Provenance{}
+	v.weight = 0
+	v.set = false
+	v.failure.Store(&failure{err: err, expiration: expiration})
+	v.published.Store((*snapshot[V])(nil))
+	for w := range v.waiters {
+		w.fail(err)
+	}
+	v.waiters = nil
+	v.bumpVersion()
+	v.emit(Update[V]{Kind: UpdateError, Time: v.clock.Now(), Old: old, Err: err})
+	v.propagate()
+}
+
+func (v *value[V]) Fail(err error) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	for w := range v.waiters {
+		w.fail(err)
+	}
+	v.waiters = nil
+}
@@ -0,0 +1,110 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetErrors(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+
+	_, err := v.Get(DontWait)
+	require.True(t, errors.Is(err, ErrNotSet))
+	require.True(t, errors.Is(err, context.Canceled))
+	require.False(t, errors.Is(err, ErrExpired))
+	require.EqualError(t, err, "eventual: value not set: context canceled")
+
+	v.SetExpiring("a", clock.Now().Add(time.Second))
+	clock.Advance(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = v.Get(ctx)
+	require.True(t, errors.Is(err, ErrExpired))
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.False(t, errors.Is(err, ErrNotSet))
+
+	v.Reset()
+	_, err = v.Get(DontWait)
+	require.True(t, errors.Is(err, ErrNotSet), "a Reset value should be reported as not set")
+}
+
+func TestSetError(t *testing.T) {
+	v := NewValue[string]()
+	fail := errors.New("config fetch failed")
+
+	errs := make(chan error)
+	go func() {
+		_, err := v.Get(context.Background())
+		errs <- err
+	}()
+	require.Eventually(t, func() bool { return v.Waiters() == 1 }, time.Second, time.Millisecond)
+	v.SetError(fail)
+	require.Equal(t, fail, <-errs, "current waiters should receive the error")
+	require.Zero(t, v.Waiters())
+
+	_, err := v.Get(context.Background())
+	require.Equal(t, fail, err, "future Gets should receive the error without waiting")
+
+	v.Set("a")
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "a", r)
+
+	v.SetError(fail)
+	_, err = v.Get(DontWait)
+	require.Equal(t, fail, err, "SetError should clear the current value")
+
+	v.Reset()
+	_, err = v.Get(DontWait)
+	require.True(t, errors.Is(err, ErrNotSet), "Reset should clear the error")
+}
+
+func TestMapSetError(t *testing.T) {
+	m := NewMap[string, string]()
+	fail := errors.New("fail")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	next, stop := iter.Pull2(m.Events(ctx))
+	defer stop()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.SetError("a", fail)
+	}()
+	key, u, ok := next()
+	require.True(t, ok)
+	require.Equal(t, "a", key)
+	require.Equal(t, UpdateError, u.Kind)
+	require.Equal(t, fail, u.Err)
+	require.Equal(t, fail, m.WaitAllSet(context.Background(), "a"))
+}
+
+func TestFail(t *testing.T) {
+	m := NewMap[string, string]()
+	fail := errors.New("shutting down")
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := m.Get(context.Background(), "a")
+			errs <- err
+		}()
+	}
+	require.Eventually(t, func() bool {
+		return m.(*emap[string, string]).getValue("a").Waiters() == 2
+	}, time.Second, time.Millisecond)
+	m.Fail("a", fail)
+	require.Equal(t, fail, <-errs)
+	require.Equal(t, fail, <-errs)
+
+	_, err := m.Get(DontWait, "a")
+	require.True(t, errors.Is(err, ErrNotSet), "Fail shouldn't affect future Gets")
+	m.Fail("unknown", fail)
+	require.Equal(t, 1, m.Stats().Keys, "Fail shouldn't track unknown keys")
+}
@@ -2,14 +2,23 @@ package eventual
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // DontWait is an expired context for use in Value.Get. Using DontWait will cause a Value.Get call
-// to return immediately. If the value has not been set, a context.Canceled error will be returned.
+// to return immediately. If the value has not been set, an error wrapping context.Canceled will be
+// returned.
 var DontWait context.Context
 
+// ErrFrozen is reported when writing to a Value that has been frozen.
+var ErrFrozen = errors.New("eventual: value is frozen")
+
 const (
 	tenYears = 10 * 365 * 24 * time.Hour
 )
@@ -22,6 +31,10 @@ func init() {
 
 // Value is an eventual value, meaning that callers wishing to access the value block until it is
 // available.
+//
+// Setting a Value never blocks on the callers that are waiting for it or watching it: they're
+// notified through buffered channels using non-blocking sends, so a slow or misbehaving consumer
+// can't stall producers.
 type Value[V comparable] interface {
 	// Set this Value.
 	Set(value V)
@@ -29,31 +42,326 @@ type Value[V comparable] interface {
 	// Set this Value, expiring at the given time.
 	SetExpiring(value V, expiration time.Time)
 
+	// SetExpiringIn sets this Value, expiring ttl from now according to the configured Clock (see
+	// WithClock).
+	SetExpiringIn(value V, ttl time.Duration)
+
+	// Touch moves the expiration of the currently set value to newExpiration without rewriting the
+	// value, for sliding expirations, and reports whether there was an unexpired value to touch.
+	// Waiters and subscribers aren't notified, since the value doesn't change.
+	Touch(newExpiration time.Time) bool
+
+	// ScheduleSet sets this Value to the given value at the given time, as told by the configured
+	// Clock (see WithClock), unless the returned cancel func is called first. Once cancel returns,
+	// the scheduled Set is guaranteed not to happen.
+	ScheduleSet(value V, at time.Time) (cancel func())
+
+	// TrySet is like Set but returns an error if the Value doesn't accept writes because it has
+	// been frozen (see Freeze) or closed (see Close).
+	TrySet(value V) error
+
+	// SetError makes the Value fail with err: current and future Gets return err instead of
+	// waiting, until the Value is Set or Reset. Any currently set value is cleared.
+	SetError(err error)
+
+	// Fail releases every caller currently blocked waiting for the Value with err, without setting
+	// a value. Unlike SetError, it doesn't affect future Gets, which wait as usual.
+	Fail(err error)
+
 	// Reset clears the currently set value, reverting to the same state as if the Eventual had just
 	// been created.
 	Reset()
 
+	// Freeze makes the Value permanently read-only in its current state. Subsequent calls to Set,
+	// SetExpiring and Reset are dropped and reported to the misuse handler (see
+	// WithMisuseHandler), and TrySet returns ErrFrozen.
+	Freeze()
+
+	// Close permanently terminates the Value. Callers blocked waiting for it are released with
+	// ErrClosed, the stored value is dropped, subsequent Gets fail immediately with ErrClosed and
+	// iterations over Updates end. Subsequent writes are dropped and reported to the misuse handler
+	// (see WithMisuseHandler), and TrySet returns ErrClosed. Close is idempotent.
+	Close()
+
 	// Get waits for the value to be set. If the context expires first, an error will be returned.
 	//
 	// This function will return immediately when called with an expired context. In this case, the
-	// value will be returned only if it has already been set; otherwise the context error will be
-	// returned. For convenience, see DontWait.
+	// value will be returned only if it has already been set. For convenience, see DontWait.
+	//
+	// When no value is available in time, the returned error wraps both the context's error and
+	// either ErrNotSet or ErrExpired, depending on whether the value was never set (or was Reset)
+	// or has expired, so callers can tell them apart with errors.Is.
 	Get(context.Context) (V, error)
 
+	// GetOrElse is like Get, but returns fallback if no value is available in time, taking the
+	// place of the Value's own default and fallbacks. This lets callers of a shared Value choose
+	// their own defaults.
+	GetOrElse(ctx context.Context, fallback V) V
+
+	// HasDefault reports whether the Value has a default (see WithDefault and WithDefaultFunc),
+	// which Get returns if a real value isn't available in time, even if the default is the zero
+	// value.
+	HasDefault() bool
+
+	// Wait waits until the Value is set, like Get, but without returning the value, for callers
+	// that only need to know that it's ready. If ctx is done first, its error is returned; defaults
+	// and fallbacks don't count as being set. If the Value fails, Wait returns the error.
+	Wait(ctx context.Context) error
+
+	// Check returns nil if the Value currently holds a value that hasn't expired, and otherwise
+	// the reason it doesn't, such as ErrNotSet, ErrExpired or the error it failed with. Defaults
+	// and fallbacks don't count. Check makes every Value a Checker.
+	Check(ctx context.Context) error
+
+	// MarshalJSON marshals the current value, or null if none is set, so that structs holding
+	// Values can be serialized directly (see WithJSONExpiration).
+	MarshalJSON() ([]byte, error)
+
+	// UnmarshalJSON sets the Value to the unmarshaled value. Unmarshaling null is a no-op.
+	UnmarshalJSON(data []byte) error
+
+	// ReadOnly returns a view of the Value that can only be read, so that APIs can hand it to
+	// consumers while keeping Set and Reset to themselves.
+	ReadOnly() Getter[V]
+
+	// WriteOnly returns a view of the Value that can only be written, for producers.
+	WriteOnly() Setter[V]
+
+	// GetStable is like Get, but only returns a value once it has remained set, without being
+	// Reset, failing or being replaced by a different value, for at least stableFor. This keeps
+	// consumers from acting on values that are still flapping while producers converge. Setting
+	// the Value to an equal value (see WithComparator) doesn't restart the wait. Defaults and
+	// fallbacks never count as stable, so if the context expires first, its error is returned.
+	GetStable(ctx context.Context, stableFor time.Duration) (V, error)
+
+	// MustGet is like Get, but panics if no value is available. It's meant for initialization code
+	// such as main, where a missing value is a programming error.
+	MustGet(context.Context) V
+
+	// Peek returns the current value and true if it's set and hasn't expired, without ever
+	// blocking. Unlike Get, it ignores defaults and fallbacks.
+	Peek() (V, bool)
+
+	// IsSet reports whether the Value currently holds an unexpired value. Defaults don't count as
+	// being set.
+	IsSet() bool
+
+	// IsExpired reports whether the Value was set but its value has since expired. It's false for
+	// a Value that was never set or has been Reset.
+	IsExpired() bool
+
+	// Expiration returns when the current value expires and true, or false if no unexpired value
+	// is set. Values stored with Set expire ten years after they were set.
+	Expiration() (time.Time, bool)
+
+	// GetWithBudget is like Get, but stops waiting reserve before the context's deadline, so that
+	// the caller has time left to execute its fallback path. If the context has no deadline, it
+	// behaves exactly like Get.
+	GetWithBudget(ctx context.Context, reserve time.Duration) (V, error)
+
+	// SetExpiringSoft sets this Value with distinct soft and hard expirations. After the soft
+	// expiration the value is stale: it's still served, but GetOrSetSoftExpiring refreshes it in the
+	// background. After the hard expiration it's unusable, as with SetExpiring.
+	SetExpiringSoft(value V, softExpiration time.Time, hardExpiration time.Time)
+
+	// GetOrSetSoftExpiring is like GetOrSetExpiring, but stores the result with distinct soft and
+	// hard expirations (see SetExpiringSoft). If the stored value is stale, it's returned
+	// immediately and getter is run in the background to refresh it, at most once at a time. If
+	// there's no usable value, getter is run synchronously as in GetOrSetExpiring.
+	GetOrSetSoftExpiring(softExpiration time.Time, hardExpiration time.Time, getter func() (V, error)) (V, error)
+
+	// GetOrSetWithFreshness is like GetOrSetSoftExpiring, but also reports whether the result was
+	// a cache hit, freshly loaded or stale, so that callers can annotate responses (e.g. with Age
+	// headers) and distinguish hits from loads in their metrics.
+	GetOrSetWithFreshness(softExpiration time.Time, hardExpiration time.Time, getter func() (V, error)) (V, Freshness, error)
+
+	// GetOrCompute waits for the Value to be set, but if that doesn't happen within the configured
+	// grace period (see WithComputeGrace), it also starts compute and returns whichever finishes
+	// first. A successfully computed value is stored unless a value was set in the meantime. This
+	// allows preferring values that are pushed while falling back to pulling them. compute's
+	// context is cancelled once GetOrCompute returns.
+	GetOrCompute(ctx context.Context, compute func(context.Context) (V, error)) (V, error)
+
 	// Gets the stored value, or if none available, runs the given func, stores the value as an expiring value,
 	// and returns the result. If func() returns an error, nothing is stored and the error is returned to caller.
+	// The func runs without blocking Get or Set, and only once at a time: concurrent callers wait for the
+	// result of the one that's already running.
 	GetOrSetExpiring(expiration time.Time, getter func() (V, error)) (V, error)
+
+	// NotifyExpiry returns a channel that's closed once the current value stops being current,
+	// because it expired or was replaced, Reset or failed, so that consumers holding resources
+	// derived from it know when to tear them down. If no value is set, the channel is already
+	// closed.
+	NotifyExpiry() <-chan struct{}
+
+	// OnExpire registers fn to be called with the value whenever a value lapses because its
+	// expiration passed, as opposed to being replaced, Reset or failing, so that cleanup or a
+	// refresh can be triggered right away. fn is called in its own goroutine.
+	OnExpire(fn func(V))
+
+	// Updates returns an iterator over the updates to this Value. It yields the current value, if
+	// set, followed by every subsequent Set, until ctx is done or the loop is exited. A consumer
+	// that's slower than the producer skips intermediate updates and only sees the latest value.
+	Updates(ctx context.Context) iter.Seq[V]
+
+	// Watch is like Updates, but delivers the updates on a channel, which is closed once ctx is
+	// done or the Value is closed. Updates that happen after Watch returns are never missed,
+	// although a slow consumer only sees the latest of them.
+	Watch(ctx context.Context) <-chan V
+
+	// Waiters returns the number of callers currently blocked waiting for this Value to be set.
+	Waiters() int
+
+	// LastError returns the error from the most recent attempt to populate the Value with a getter
+	// (see GetOrSetExpiring), along with when it happened. If the most recent attempt succeeded or
+	// there was none, it returns a nil error and the zero time. This allows health checks to
+	// explain why a value is missing.
+	LastError() (error, time.Time)
+
+	// Version returns the Value's current version, which starts at 0 and is incremented on every
+	// Set and Reset.
+	Version() uint64
+
+	// Changed reports whether the Value has been Set or Reset since it was at the given version.
+	// Together with Version, this allows pollers to cheaply detect changes.
+	Changed(since uint64) bool
+
+	// GetAtLeast is like Get, but first waits for the Value to reach at least minVersion (see
+	// Version), for example to wait for a config to be reloaded at least once after a given point.
+	GetAtLeast(ctx context.Context, minVersion uint64) (V, error)
+
+	// GetValid is like Get, but only returns a value that passes validate. If the current value
+	// doesn't, it waits for the Value to be set to one that does, for example to replace a
+	// certificate that's present but already revoked. Refreshing Values refresh right away when
+	// their current value fails validation. If ctx is done first, GetValid returns an error
+	// wrapping both ctx's error and the last validation error. Defaults and fallbacks have to pass
+	// validate too.
+	GetValid(ctx context.Context, validate func(V) error) (V, error)
+
+	// Pin returns the current value and keeps it from expiring until unpin is called, so that
+	// long-running operations can rely on it throughout. A value that would have expired in the
+	// meantime expires when the last caller unpins it. Pinning doesn't keep the value from being
+	// replaced, Reset or failing, and unpinning a value that was replaced has no effect. If no
+	// value is set, Pin returns an error instead, along with a nil unpin.
+	Pin() (value V, unpin func(), err error)
+
+	// History returns the most recent values the Value was set to, oldest first, if it's
+	// configured to keep a history (see WithHistory).
+	History() []Entry[V]
+
+	// EqualTo reports whether the Value is currently set to something equal to the given value,
+	// according to the configured comparator (see WithComparator).
+	EqualTo(value V) bool
+
+	// SetWithProvenance is like Set, but records where the value came from. The provenance is
+	// available from Provenance for as long as the value is current, and is included in the Map's
+	// Events.
+	SetWithProvenance(value V, provenance Provenance)
+
+	// Provenance returns the provenance of the current value, as given to SetWithProvenance. It's
+	// the zero Provenance if no value is set or the current value was set without one.
+	Provenance() Provenance
+
+	// SetIfAbsent sets the Value only if it isn't currently set, or has expired, and reports
+	// whether it did. When producers race to populate the Value, the first writer wins.
+	SetIfAbsent(value V) bool
+
+	// Update atomically replaces the Value with the result of fn, which is called with the current
+	// value and whether it's set, while holding the Value's lock. fn must not access the Value. The
+	// current expiration is kept; if the Value isn't set, the result is stored as with Set. This
+	// avoids the race between reading the Value and setting it.
+	Update(fn func(current V, set bool) V)
+
+	// CompareAndSet atomically sets the Value to new if it's currently set to something equal to
+	// old, according to the configured comparator (see WithComparator), and reports whether it did.
+	// This enables optimistic updates without external locking.
+	CompareAndSet(old V, new V) bool
+
+	// Clone returns an independent Value with the same configuration, default and current value,
+	// including its expiration and provenance, but none of its waiters or subscribers. Payloads
+	// are copied by assignment, so anything they point to is shared.
+	Clone() Value[V]
+
+	// Weight returns the approximate size in bytes of the stored payload as reported by the
+	// configured weigher (see WithWeigher), or 0 if there's no weigher or no value is set.
+	Weight() int
 }
 
 // NewValue creates a new value.
-func NewValue[V comparable]() Value[V] {
-	return &value[V]{}
+func NewValue[V comparable](opts ...Option) Value[V] {
+	return newValue[V](opts)
 }
 
 // WithDefault creates a new value that returns the given defaultValue if a real value isn't
 // available in time.
-func WithDefault[V comparable](defaultValue V) Value[V] {
-	return &value[V]{defaultValue: defaultValue}
+func WithDefault[V comparable](defaultValue V, opts ...Option) Value[V] {
+	v := newValue[V](opts)
+	v.defaultValue = defaultValue
+	v.hasDefault = true
+	return v
+}
+
+// WithDefaultFunc is like WithDefault, but the default is computed by calling fn the first time a
+// real value isn't available in time, which avoids building an expensive default that's usually
+// not needed. fn is called at most once.
+func WithDefaultFunc[V comparable](fn func() V, opts ...Option) Value[V] {
+	v := newValue[V](opts)
+	v.defaultFn = fn
+	v.hasDefault = true
+	return v
+}
+
+// NewValueFrom creates a new value that's already set to v. This allows code that sometimes has
+// the value right away to return a Value either way.
+func NewValueFrom[V comparable](v V, opts ...Option) Value[V] {
+	result := newValue[V](opts)
+	result.Set(v)
+	return result
+}
+
+// NewValueFromExpiring is like NewValueFrom, but the value expires at the given time.
+func NewValueFromExpiring[V comparable](v V, expiration time.Time, opts ...Option) Value[V] {
+	result := newValue[V](opts)
+	result.SetExpiring(v, expiration)
+	return result
+}
+
+func newValue[V comparable](opts []Option) *value[V] {
+	return newValueExact[V](withDefaults(opts))
+}
+
+// newValueExact is like newValue, but doesn't apply the defaults set with SetDefaults, because
+// opts already include them.
+func newValueExact[V comparable](opts []Option) *value[V] {
+	o := buildOptions(opts)
+	fallbacks := make([]Value[V], 0, len(o.fallbacks))
+	for _, fallback := range o.fallbacks {
+		fallbacks = append(fallbacks, typedOption[Value[V]]("WithFallback", fallback))
+	}
+	var q *quorum[V]
+	if o.quorum > 0 {
+		q = newQuorum(o.quorum, typedOption[func(V, V) V]("WithQuorum", o.reducer))
+	}
+	return &value[V]{
+		quorum:    q,
+		fallbacks: fallbacks,
+		weigher:   typedOption[func(V) int]("WithWeigher", o.weigher),
+		equal:     typedOption[func(V, V) bool]("WithComparator", o.comparator),
+		misuse:    o.misuse,
+		clock:     o.clock,
+		grace:     o.computeGrace,
+		interval:  o.notifyInterval,
+		diagnose:  o.diagnostics,
+		maxAge:    o.maxAge,
+
+		historySize:        o.history,
+		defaultOnImmediate: o.defaultOnImmediate,
+		defaultReporter:    o.defaultReporter,
+		jsonExpiration:     o.jsonExpiration,
+		visiblePayloads:    o.visiblePayloads,
+		sensitive:          o.sensitive,
+		audit:              o.audit,
+	}
 }
 
 type value[V comparable] struct {
@@ -62,86 +370,770 @@ type value[V comparable] struct {
 	zeroValue    V
 	defaultValue V
 	expiration   time.Time
+	// softExpiration is when the value becomes stale, meaning that it's still usable but should
+	// be refreshed. It's never after expiration.
+	softExpiration time.Time
+	refreshing     bool
+	diagnose       bool
+	maxAge         time.Duration
+	everSet        bool
+	// inflight is the getter that's currently populating the value, if any.
+	inflight *call[V]
+	// defaultFn, if set, computes computedDefault the first time it's needed (see
+	// WithDefaultFunc).
+	hasDefault      bool
+	defaultFn       func() V
+	defaultOnce     sync.Once
+	computedDefault V
+	// defaultOnImmediate is configured by DefaultOnImmediateGet.
+	defaultOnImmediate bool
+	// defaultReporter, if not nil, is told whenever a Get falls back to the default (see
+	// WithDefaultFallbackHandler).
+	defaultReporter *defaultReporter
+	// jsonExpiration is configured by WithJSONExpiration.
+	jsonExpiration bool
+	// visiblePayloads is configured by WithVisiblePayloads.
+	visiblePayloads bool
+	// audit is configured by WithAuditHook.
+	audit func(UpdateKind, time.Time, string)
+	// watched, if set, reports whether anyone observes onUpdate closely enough to be told about
+	// expiry, such as a Map's Events subscribers.
+	watched func() bool
+	// quorum, if not nil, tracks the producers of a Value that needs a quorum (see WithQuorum).
+	quorum *quorum[V]
+	// history holds up to historySize of the most recent values, oldest first (see WithHistory).
+	history     []Entry[V]
+	historySize int
+	// node is the Value's place in the graph of derived Values (see Transform and Combine).
+	node node
+	// loads is the number of getters, refreshers and loaders currently running outside of the
+	// mutex, and loadingSince is when the first of the currently overlapping ones started.
+	loads        int
+	loadingSince time.Time
+	provenance   Provenance
 	set          bool
-	waiters      []chan V
+	sensitive    bool
+	weigher      func(V) int
+	weight       int
+	onUpdate     func(Update[V])
+	frozen       bool
+	closed       atomic.Bool // only written while holding the mutex
+	// failure holds the error that Gets fail with instead of waiting, if any (see SetError and
+	// Close). It's only written while holding the mutex.
+	failure     atomic.Pointer[failure]
+	misuse      func(error)
+	equal       func(V, V) bool
+	version     uint64 // accessed atomically, only written while holding the mutex
+	clock       Clock
+	grace       time.Duration
+	interval    time.Duration // minimum time between updates yielded by Updates
+	fallbacks   []Value[V]
+	lastErr     error
+	lastErrAt   time.Time
+	waiters     map[*waiter[V]]struct{}
+	subscribers map[*subscriber[V]]struct{}
+	// versionChanged, if not nil, is closed the next time version changes.
+	versionChanged chan struct{}
+	// expiry, if not nil, is closed once the current value stops being current, which
+	// expiryTimer takes care of when it expires.
+	expiry      chan struct{}
+	expiryTimer Timer
+	// expireFns are called when a value lapses (see OnExpire), which lapse takes care of.
+	expireFns []func(V)
+	lapse     *lapse
+	// pins is the number of callers that are using the current value (see Pin).
+	pins int
+	// published holds a *snapshot[V] of the current state so that Get can read an already set
+	// value without taking the mutex. It's only ever written while holding the mutex.
+	published atomic.Value
+}
+
+// snapshot is an immutable copy of a value's state. A nil snapshot means that no value is set.
+type snapshot[V comparable] struct {
+	v              V
+	expiration     time.Time
+	softExpiration time.Time
+	// since is when the Value was set to v, ignoring Sets to equal values in the meantime.
+	since time.Time
+	// pinned is whether v stays valid past its expiration because it's pinned (see Pin).
+	pinned bool
+}
+
+func (s *snapshot[V]) valid(now time.Time) bool {
+	return s != nil && (s.pinned || s.expiration.IsZero() || s.expiration.After(now))
+}
+
+func (v *value[V]) snapshot() *snapshot[V] {
+	s, _ := v.published.Load().(*snapshot[V])
+	return s
 }
 
 func (v *value[V]) Set(i V) {
-	v.SetExpiring(i, time.Now().Add(tenYears))
+	v.SetExpiring(i, v.clock.Now().Add(tenYears))
 }
 
 func (v *value[V]) SetExpiring(i V, t time.Time) {
+	if err := v.trySetExpiring(i, t); err != nil {
+		v.reportMisuse(err)
+	}
+}
+
+func (v *value[V]) SetExpiringIn(i V, ttl time.Duration) {
+	v.SetExpiring(i, v.clock.Now().Add(ttl))
+}
+
+func (v *value[V]) Touch(t time.Time) bool {
 	v.m.Lock()
+	if err := v.writable(); err != nil {
+		v.m.Unlock()
+		v.reportMisuse(err)
+		return false
+	}
+	defer v.m.Unlock()
+	s := v.snapshot()
+	now := v.clock.Now()
+	if !s.valid(now) {
+		return false
+	}
+	if v.maxAge > 0 && t.After(now.Add(v.maxAge)) {
+		t = now.Add(v.maxAge)
+	}
+	soft := s.softExpiration
+	if soft.Equal(s.expiration) || soft.After(t) {
+		// There's no separate soft expiration, or it would be after the hard one
+		soft = t
+	}
+	v.expiration = t
+	v.softExpiration = soft
+	v.published.Store(&snapshot[V]{v: s.v, expiration: t, softExpiration: soft, since: s.since, pinned: v.pins > 0})
+	if v.expiry != nil {
+		v.scheduleExpiry()
+	}
+	v.scheduleLapse()
+	v.propagate()
+	return true
+}
+
+func (v *value[V]) TrySet(i V) error {
+	return v.trySetExpiring(i, v.clock.Now().Add(tenYears))
+}
+
+func (v *value[V]) trySetExpiring(i V, t time.Time) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+	if err := v.writable(); err != nil {
+		return err
+	}
 	v.doSetExpiring(i, t)
+	return nil
+}
+
+func (v *value[V]) ScheduleSet(i V, at time.Time) func() {
+	cancelled := false
+	timer := v.clock.AfterFunc(at.Sub(v.clock.Now()), func() {
+		v.m.Lock()
+		if cancelled {
+			v.m.Unlock()
+			return
+		}
+		if err := v.writable(); err != nil {
+			v.m.Unlock()
+			v.reportMisuse(err)
+			return
+		}
+		v.doSetExpiring(i, v.clock.Now().Add(tenYears))
+		v.m.Unlock()
+	})
+	return func() {
+		timer.Stop()
+		v.m.Lock()
+		cancelled = true
+		v.m.Unlock()
+	}
+}
+
+func (v *value[V]) Freeze() {
+	v.m.Lock()
+	v.frozen = true
 	v.m.Unlock()
 }
 
+// writable returns an error if the value doesn't accept writes. Must be called while holding the
+// mutex.
+func (v *value[V]) writable() error {
+	if v.closed.Load() {
+		return ErrClosed
+	}
+	if v.frozen {
+		return ErrFrozen
+	}
+	return nil
+}
+
+// reportMisuse passes err to the misuse handler, if any. Must not be called while holding the
+// mutex.
+func (v *value[V]) reportMisuse(err error) {
+	if v.misuse != nil {
+		v.misuse(err)
+	}
+}
+
 func (v *value[V]) doSetExpiring(i V, t time.Time) {
+	v.doSet(i, t, t, Provenance{})
+}
+
+// doSet sets the value with the given soft and hard expirations and provenance. Must be called
+// while holding the mutex.
+func (v *value[V]) doSet(i V, soft time.Time, t time.Time, p Provenance) {
+	if v.quorum != nil {
+		var reached bool
+		if i, reached = v.quorum.add(p.Source, i); !reached {
+			return
+		}
+	}
+	now := v.clock.Now()
+	if v.maxAge > 0 {
+		limit := now.Add(v.maxAge)
+		if t.After(limit) {
+			t = limit
+		}
+		if soft.After(limit) {
+			soft = limit
+		}
+	}
+	old := v.previous()
+	since := now
+	if s := v.snapshot(); s.valid(since) && v.equals(s.v, i) {
+		since = s.since
+	}
+	v.endExpiry()
+	v.pins = 0
 	v.v = i
 	v.expiration = t
-	if !v.set {
-		// This is our first time setting, inform anyone who is waiting
-		for _, waiter := range v.waiters {
-			waiter <- i
-		}
-		v.waiters = make([]chan V, 0)
-		v.expiration = t
-		v.set = true
+	v.softExpiration = soft
+	v.provenance = p
+	if v.weigher != nil {
+		v.weight = v.weigher(i)
+	}
+	v.set = true
+	v.everSet = true
+	v.failure.Store(nil)
+	v.published.Store(&snapshot[V]{v: i, expiration: t, softExpiration: soft, since: since})
+	// Inform anyone who is waiting, after publishing the value so that they can Get it right away.
+	// Waiters only exist while there's no valid value, i.e. before the first Set, after a Reset or
+	// after the value expired.
+	for w := range v.waiters {
+		w.notify(i)
+	}
+	v.waiters = nil
+	v.bumpVersion()
+	v.record(i, now)
+	for sub := range v.subscribers {
+		sub.notify(i)
+	}
+	v.scheduleLapse()
+	v.emit(Update[V]{Kind: UpdateSet, Time: now, Value: i, Old: old, Provenance: p})
+	v.propagate()
+}
+
+// previous returns the current value for inclusion in an Update, or the zero value if there's none
+// or it's a secret (see NewSecret). Must be called while holding the mutex.
+func (v *value[V]) previous() V {
+	if !v.set || v.sensitive {
+		return v.zeroValue
 	}
+	return v.v
 }
 
 func (v *value[V]) Reset() {
 	v.m.Lock()
+	if err := v.writable(); err != nil {
+		v.m.Unlock()
+		v.reportMisuse(err)
+		return
+	}
+	v.doReset()
+	v.m.Unlock()
+}
+
+// doReset clears the value. Must be called while holding the mutex.
+func (v *value[V]) doReset() {
+	old := v.previous()
+	v.endExpiry()
+	v.stopLapse()
+	v.pins = 0
+	if v.quorum != nil {
+		v.quorum.clear()
+	}
+	if v.sensitive {
+		zeroBytes(v.v)
+	}
 	v.v = v.zeroValue
 	v.expiration = time.Time{}
+	v.softExpiration = time.Time{}
+	v.provenance = Provenance{}
+	v.weight = 0
 	v.set = false
-	v.m.Unlock()
+	v.failure.Store(nil)
+	v.published.Store((*snapshot[V])(nil))
+	v.bumpVersion()
+	v.emit(Update[V]{Kind: UpdateReset, Time: v.clock.Now(), Old: old})
+	v.propagate()
 }
 
 func (v *value[V]) Get(ctx context.Context) (V, error) {
+	if s := v.snapshot(); s.valid(v.clock.Now()) {
+		// Value already set, use existing without locking or allocating
+		return s.v, nil
+	}
+	if ctx.Err() != nil && v.defaultOnImmediate && v.hasDefault {
+		return v.fallBackToDefault(time.Time{}), nil
+	}
+	if err := v.failed(); err != nil {
+		return v.zeroValue, err
+	}
+	if ctx.Err() != nil {
+		// Caller isn't willing to wait, don't bother registering a waiter
+		return v.contextDone(ctx, time.Time{})
+	}
+
+	w, _v, ok := v.waiter()
+	if ok {
+		return _v, nil
+	}
+	select {
+	case o := <-w.ch:
+		return o.v, o.err
+	case <-ctx.Done():
+		v.removeWaiter(w)
+		return v.contextDone(ctx, w.since)
+	}
+}
+
+func (v *value[V]) HasDefault() bool {
+	return v.hasDefault
+}
+
+// tryGet is like Get with DontWait, but reports why no value is available instead of the
+// context's error.
+func (v *value[V]) tryGet() (V, error) {
+	if result, err := v.Get(DontWait); err == nil {
+		return result, nil
+	}
 	v.m.Lock()
-	if v.set {
-		if v.expiration.IsZero() || v.expiration.After(time.Now()) {
-			// Value already set, use existing
-			_v := v.v
-			v.m.Unlock()
-			return _v, nil
+	defer v.m.Unlock()
+	if err := v.unavailable(); err != nil {
+		return v.zeroValue, err
+	}
+	// Set in the meantime
+	return v.snapshot().v, nil
+}
+
+func (v *value[V]) GetOrElse(ctx context.Context, fallback V) V {
+	if v.Wait(ctx) == nil {
+		if result, ok := v.Peek(); ok {
+			return result
 		}
 	}
+	return fallback
+}
 
-	// Value not yet set, wait
-	waiter := make(chan V, 1)
-	v.waiters = append(v.waiters, waiter)
-	v.m.Unlock()
+func (v *value[V]) MustGet(ctx context.Context) V {
+	result, err := v.Get(ctx)
+	if err != nil {
+		panic(fmt.Sprintf("eventual: MustGet: %v", err))
+	}
+	return result
+}
+
+func (v *value[V]) Peek() (V, bool) {
+	if s := v.snapshot(); s.valid(v.clock.Now()) {
+		return s.v, true
+	}
+	return v.zeroValue, false
+}
+
+func (v *value[V]) IsSet() bool {
+	return v.snapshot().valid(v.clock.Now())
+}
+
+func (v *value[V]) IsExpired() bool {
+	s := v.snapshot()
+	return s != nil && !s.valid(v.clock.Now())
+}
+
+func (v *value[V]) Expiration() (time.Time, bool) {
+	if s := v.snapshot(); s.valid(v.clock.Now()) {
+		return s.expiration, true
+	}
+	return time.Time{}, false
+}
+
+func (v *value[V]) GetWithBudget(ctx context.Context, reserve time.Duration) (V, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return v.Get(ctx)
+	}
+	ctx, cancel := context.WithDeadline(ctx, deadline.Add(-reserve))
+	defer cancel()
+	return v.Get(ctx)
+}
+
+func (v *value[V]) Wait(ctx context.Context) error {
+	if s := v.snapshot(); s.valid(v.clock.Now()) {
+		return nil
+	}
+	if err := v.failed(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	w, _, ok := v.waiter()
+	if ok {
+		return nil
+	}
 	select {
-	case _v := <-waiter:
-		return _v, nil
+	case o := <-w.ch:
+		return o.err
 	case <-ctx.Done():
-		if v.defaultValue != v.zeroValue {
-			return v.defaultValue, nil
+		v.removeWaiter(w)
+		return ctx.Err()
+	}
+}
+
+// waiter is a caller blocked waiting for a value to be set.
+type waiter[V comparable] struct {
+	// ch receives the outcome of the wait: the next Set, or the error that the value failed with
+	// (see SetError). It's buffered so that Set never blocks on delivering to it.
+	ch    chan outcome[V]
+	since time.Time
+}
+
+// outcome is what a waiter receives.
+type outcome[V comparable] struct {
+	v   V
+	err error
+}
+
+// notify delivers i to the waiter without blocking. Every waiter is notified at most once, so its
+// buffered channel always has room; the non-blocking send guarantees that a broken invariant can
+// never block Set while it's holding the value's mutex.
+func (w *waiter[V]) notify(i V) {
+	w.deliver(outcome[V]{v: i})
+}
+
+// fail delivers err to the waiter without blocking. See notify.
+func (w *waiter[V]) fail(err error) {
+	w.deliver(outcome[V]{err: err})
+}
+
+func (w *waiter[V]) deliver(o outcome[V]) {
+	select {
+	case w.ch <- o:
+	default:
+	}
+}
+
+// waiter registers a waiter to which the next Set will be delivered. If a value was set in the
+// meantime, it's returned instead along with true. If the value failed in the meantime, the
+// returned waiter has already received the error.
+func (v *value[V]) waiter() (*waiter[V], V, bool) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	if s := v.snapshot(); s.valid(v.clock.Now()) {
+		return nil, s.v, true
+	}
+
+	w := &waiter[V]{ch: make(chan outcome[V], 1), since: v.clock.Now()}
+	if err := v.failed(); err != nil {
+		w.fail(err)
+		return w, v.zeroValue, false
+	}
+	if v.waiters == nil {
+		v.waiters = make(map[*waiter[V]]struct{})
+	}
+	v.waiters[w] = struct{}{}
+	return w, v.zeroValue, false
+}
+
+// removeWaiter deregisters a waiter that gave up waiting.
+func (v *value[V]) removeWaiter(w *waiter[V]) {
+	v.m.Lock()
+	delete(v.waiters, w)
+	v.m.Unlock()
+}
+
+func (v *value[V]) Waiters() int {
+	v.m.Lock()
+	defer v.m.Unlock()
+	return len(v.waiters)
+}
+
+// pending returns the number of waiters and when the oldest of them started waiting.
+func (v *value[V]) pending() (int, time.Time) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	var oldest time.Time
+	for w := range v.waiters {
+		if oldest.IsZero() || w.since.Before(oldest) {
+			oldest = w.since
 		}
-		return v.defaultValue, ctx.Err()
 	}
+	return len(v.waiters), oldest
+}
+
+// contextDone returns the result of a Get whose context expired before a value was available.
+func (v *value[V]) contextDone(ctx context.Context, since time.Time) (V, error) {
+	for _, fallback := range v.fallbacks {
+		if result, err := fallback.Get(DontWait); err == nil {
+			return result, nil
+		}
+	}
+	if v.hasDefault {
+		return v.fallBackToDefault(since), nil
+	}
+	s := v.snapshot()
+	err := unavailable(s != nil && !s.valid(v.clock.Now()), ctx.Err())
+	if v.diagnose {
+		err = v.diagnosed(err)
+	}
+	return v.defaultValue, err
+}
+
+// getDefault returns the Value's default, computing it first if it's configured with
+// WithDefaultFunc.
+func (v *value[V]) getDefault() V {
+	if v.defaultFn != nil {
+		v.defaultOnce.Do(func() {
+			v.computedDefault = v.defaultFn()
+		})
+		return v.computedDefault
+	}
+	return v.defaultValue
 }
 
 func (v *value[V]) GetOrSetExpiring(t time.Time, getter func() (V, error)) (V, error) {
 	v.m.Lock()
-	if v.set {
-		if v.expiration.IsZero() || v.expiration.After(time.Now()) {
-			// Value already set, use existing
-			_v := v.v
+	if s := v.snapshot(); s.valid(v.clock.Now()) {
+		// Value already set, use existing
+		v.m.Unlock()
+		return s.v, nil
+	}
+	if v.closed.Load() {
+		v.m.Unlock()
+		return v.zeroValue, ErrClosed
+	}
+
+	// Value not yet set, get it
+	return v.load(getter, func(i V, err error) (V, error) {
+		if err != nil {
+			return v.zeroValue, err
+		}
+		if v.writable() == nil {
+			v.doSetExpiring(i, t)
+		}
+		return i, nil
+	})
+}
+
+// recordError records the result of a getter. Must be called while holding the mutex.
+func (v *value[V]) recordError(err error) {
+	v.lastErr = err
+	v.lastErrAt = v.clock.Now()
+}
+
+func (v *value[V]) LastError() (error, time.Time) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	if v.lastErr == nil {
+		return nil, time.Time{}
+	}
+	return v.lastErr, v.lastErrAt
+}
+
+func (v *value[V]) Version() uint64 {
+	return atomic.LoadUint64(&v.version)
+}
+
+func (v *value[V]) Changed(since uint64) bool {
+	return v.Version() != since
+}
+
+// bumpVersion increments the version. Must be called while holding the mutex.
+func (v *value[V]) bumpVersion() {
+	atomic.AddUint64(&v.version, 1)
+	if v.versionChanged != nil {
+		close(v.versionChanged)
+		v.versionChanged = nil
+	}
+}
+
+func (v *value[V]) GetAtLeast(ctx context.Context, minVersion uint64) (V, error) {
+	start := v.clock.Now()
+	if err := v.awaitVersion(ctx, minVersion); err != nil {
+		if err == ErrClosed {
+			return v.zeroValue, err
+		}
+		return v.contextDone(ctx, start)
+	}
+	return v.Get(ctx)
+}
+
+// awaitVersion waits until the Value reaches at least minVersion. It returns ErrClosed if the Value
+// is closed first, or ctx's error if ctx is done first.
+func (v *value[V]) awaitVersion(ctx context.Context, minVersion uint64) error {
+	for {
+		v.m.Lock()
+		if atomic.LoadUint64(&v.version) >= minVersion {
 			v.m.Unlock()
-			return _v, nil
+			return nil
+		}
+		if v.closed.Load() {
+			v.m.Unlock()
+			return ErrClosed
+		}
+		if v.versionChanged == nil {
+			v.versionChanged = make(chan struct{})
 		}
+		changed := v.versionChanged
+		v.m.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (v *value[V]) EqualTo(i V) bool {
+	s := v.snapshot()
+	if !s.valid(v.clock.Now()) {
+		return false
 	}
+	return v.equals(s.v, i)
+}
 
-	// Value not yet set, get it
-	i, err := getter()
-	if err != nil {
+// equals compares a and b using the configured comparator, if any.
+func (v *value[V]) equals(a V, b V) bool {
+	if v.equal != nil {
+		return v.equal(a, b)
+	}
+	return a == b
+}
+
+func (v *value[V]) SetIfAbsent(i V) bool {
+	v.m.Lock()
+	if err := v.writable(); err != nil {
 		v.m.Unlock()
-		return v.zeroValue, err
+		v.reportMisuse(err)
+		return false
 	}
-	v.doSetExpiring(i, t)
-	v.m.Unlock()
-	return i, nil
+	defer v.m.Unlock()
+	if v.snapshot().valid(v.clock.Now()) {
+		return false
+	}
+	v.doSetExpiring(i, v.clock.Now().Add(tenYears))
+	return true
+}
+
+func (v *value[V]) Update(fn func(current V, set bool) V) {
+	v.m.Lock()
+	if err := v.writable(); err != nil {
+		v.m.Unlock()
+		v.reportMisuse(err)
+		return
+	}
+	defer v.m.Unlock()
+	s := v.snapshot()
+	if !s.valid(v.clock.Now()) {
+		t := v.clock.Now().Add(tenYears)
+		v.doSetExpiring(fn(v.zeroValue, false), t)
+		return
+	}
+	v.doSet(fn(s.v, true), s.softExpiration, s.expiration, v.provenance)
+}
+
+func (v *value[V]) CompareAndSet(old V, i V) bool {
+	v.m.Lock()
+	if err := v.writable(); err != nil {
+		v.m.Unlock()
+		v.reportMisuse(err)
+		return false
+	}
+	defer v.m.Unlock()
+	if s := v.snapshot(); !s.valid(v.clock.Now()) || !v.equals(s.v, old) {
+		return false
+	}
+	v.doSetExpiring(i, v.clock.Now().Add(tenYears))
+	return true
+}
+
+func (v *value[V]) Weight() int {
+	v.m.Lock()
+	defer v.m.Unlock()
+	if !v.set {
+		return 0
+	}
+	return v.weight
+}
+
+// maxStringPayload is the length beyond which String abbreviates payloads.
+const maxStringPayload = 64
+
+// String implements fmt.Stringer. It describes the Value's state for debugging, for example
+// "string(len=5, hash=4f9f2cab) (expires in 5s)" or "<unset> (2 waiters)". Payloads are
+// summarized by their type, and the length and a hash of their fmt representation, so that
+// credentials don't end up in logs, unless the Value is configured with WithVisiblePayloads. The
+// payload of a secret value (see NewSecret) is always redacted.
+func (v *value[V]) String() string {
+	v.m.Lock()
+	defer v.m.Unlock()
+	now := v.clock.Now()
+	s := v.snapshot()
+	var state string
+	var details []string
+	switch {
+	case s.valid(now):
+		state = v.payloadString(s.v)
+		if remaining := s.expiration.Sub(now); !s.expiration.IsZero() && remaining < tenYears/2 {
+			details = append(details, fmt.Sprintf("expires in %v", remaining))
+		}
+	case v.failed() != nil:
+		state = fmt.Sprintf("<failed: %v>", v.failed())
+	case s != nil:
+		state = fmt.Sprintf("<expired %v ago>", now.Sub(s.expiration))
+	default:
+		state = "<unset>"
+	}
+	if len(v.waiters) > 0 {
+		details = append(details, fmt.Sprintf("%d waiters", len(v.waiters)))
+	}
+	if len(details) == 0 {
+		return state
+	}
+	return state + " (" + strings.Join(details, ", ") + ")"
+}
+
+// payloadString formats i for String.
+func (v *value[V]) payloadString(i V) string {
+	if v.sensitive {
+		return redacted
+	}
+	formatted := fmt.Sprint(i)
+	if !v.visiblePayloads {
+		return fmt.Sprintf("%T(len=%d, hash=%08x)", i, len(formatted), hashString(formatted))
+	}
+	result := []rune(formatted)
+	if len(result) > maxStringPayload {
+		return string(result[:maxStringPayload]) + "..."
+	}
+	return formatted
+}
+
+// GoString implements fmt.GoStringer so that %#v doesn't bypass redaction of secret values.
+func (v *value[V]) GoString() string {
+	return v.String()
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -42,7 +44,7 @@ func TestSingle(t *testing.T) {
 	require.NoError(t, err, "Get with expired context should have succeeded")
 	require.Equal(t, v2, result)
 
-	require.Zero(t, len(v.(*value[string]).waiters), "value should have no remaining waiters")
+	require.Zero(t, v.Waiters(), "value should have no remaining waiters")
 
 	v.Reset()
 	_, err = v.Get(shortTimeoutCtx)
@@ -169,6 +171,208 @@ func TestSetExpiring(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestZeroDefault(t *testing.T) {
+	v := WithDefault(0)
+	require.True(t, v.HasDefault())
+	r, err := v.Get(DontWait)
+	require.NoError(t, err, "zero default should be honored")
+	require.Zero(t, r)
+	require.False(t, NewValue[int]().HasDefault())
+
+	m := NewMapWithDefaults(map[string]string{"a": ""})
+	_, err = m.Get(DontWait, "a")
+	require.NoError(t, err)
+	_, err = m.Get(DontWait, "b")
+	require.Error(t, err)
+}
+
+func TestDefaultOnImmediateGet(t *testing.T) {
+	fail := errors.New("fail")
+	fallback := NewValueFrom("fallback")
+
+	v := WithDefault("default", WithFallback(fallback))
+	v.SetError(fail)
+	_, err := v.Get(DontWait)
+	require.True(t, errors.Is(err, fail), "failure should be returned without the option")
+	v.Reset()
+	r, _ := v.Get(DontWait)
+	require.Equal(t, "fallback", r, "fallback should be consulted without the option")
+
+	v = WithDefault("default", WithFallback(fallback), DefaultOnImmediateGet())
+	v.SetError(fail)
+	r, err = v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "default", r)
+	v.Reset()
+	r, err = v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "default", r, "default should take precedence over fallbacks")
+
+	v.Set("a")
+	r, err = v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "a", r, "current value should take precedence over the default")
+
+	_, err = NewValue[string](DefaultOnImmediateGet()).Get(DontWait)
+	require.Error(t, err, "option should have no effect without a default")
+}
+
+func TestWithDefaultFunc(t *testing.T) {
+	calls := 0
+	v := WithDefaultFunc(func() string {
+		calls++
+		return "default"
+	})
+	v.Set("a")
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "a", r)
+	require.Zero(t, calls, "default shouldn't be computed while a value is available")
+
+	v.Reset()
+	for i := 0; i < 2; i++ {
+		r, err = v.Get(DontWait)
+		require.NoError(t, err)
+		require.Equal(t, "default", r)
+	}
+	require.Equal(t, 1, calls, "default should only be computed once")
+}
+
+func TestNewValueFrom(t *testing.T) {
+	v := NewValueFrom("a")
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "a", r)
+
+	clock := newFakeClock()
+	v = NewValueFromExpiring("b", clock.Now().Add(time.Second), WithClock(clock))
+	r, err = v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "b", r)
+	clock.Advance(time.Second)
+	require.True(t, v.IsExpired())
+}
+
+func TestGetOrElse(t *testing.T) {
+	v := WithDefault[string]("default")
+	require.Equal(t, "mine", v.GetOrElse(DontWait, "mine"), "per-call fallback should take precedence")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		v.Set("a")
+	}()
+	require.Equal(t, "a", v.GetOrElse(context.Background(), "mine"))
+
+	v.SetError(errors.New("fail"))
+	require.Equal(t, "mine", v.GetOrElse(context.Background(), "mine"), "failed value should return fallback")
+}
+
+func TestMustGet(t *testing.T) {
+	v := NewValue[string]()
+	require.PanicsWithValue(t, "eventual: MustGet: eventual: value not set: context canceled", func() {
+		v.MustGet(DontWait)
+	})
+	v.Set("a")
+	require.Equal(t, "a", v.MustGet(DontWait))
+}
+
+func TestPeek(t *testing.T) {
+	clock := newFakeClock()
+	v := WithDefault[string]("default", WithClock(clock))
+	_, ok := v.Peek()
+	require.False(t, ok, "Peek should ignore the default")
+
+	v.SetExpiring("hi", clock.Now().Add(time.Second))
+	r, ok := v.Peek()
+	require.True(t, ok)
+	require.Equal(t, "hi", r)
+
+	clock.Advance(time.Second)
+	r, ok = v.Peek()
+	require.False(t, ok, "Peek should ignore expired values")
+	require.Empty(t, r)
+}
+
+func TestIsSetAndIsExpired(t *testing.T) {
+	clock := newFakeClock()
+	v := WithDefault[string]("default", WithClock(clock))
+	require.False(t, v.IsSet(), "defaults shouldn't count as being set")
+	require.False(t, v.IsExpired())
+
+	v.SetExpiring("hi", clock.Now().Add(time.Second))
+	require.True(t, v.IsSet())
+	require.False(t, v.IsExpired())
+
+	clock.Advance(time.Second)
+	require.False(t, v.IsSet())
+	require.True(t, v.IsExpired())
+
+	v.Reset()
+	require.False(t, v.IsSet())
+	require.False(t, v.IsExpired(), "a Reset value isn't expired")
+}
+
+func TestExpiration(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	_, ok := v.Expiration()
+	require.False(t, ok)
+
+	expiration := clock.Now().Add(time.Second)
+	v.SetExpiring("hi", expiration)
+	r, ok := v.Expiration()
+	require.True(t, ok)
+	require.Equal(t, expiration, r)
+
+	clock.Advance(time.Second)
+	_, ok = v.Expiration()
+	require.False(t, ok, "expired values shouldn't report an expiration")
+}
+
+func TestTouch(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	require.False(t, v.Touch(clock.Now().Add(time.Minute)), "unset value can't be touched")
+
+	v.SetExpiring("a", clock.Now().Add(time.Second))
+	version := v.Version()
+	require.True(t, v.Touch(clock.Now().Add(time.Minute)))
+	require.Equal(t, version, v.Version(), "touching shouldn't count as a change")
+	clock.Advance(30 * time.Second)
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "a", r)
+
+	clock.Advance(30 * time.Second)
+	require.False(t, v.Touch(clock.Now().Add(time.Minute)), "expired value can't be touched")
+
+	m := NewMap[string, string](WithClock(clock))
+	require.False(t, m.Touch("a", clock.Now().Add(time.Minute)))
+	m.SetExpiring("a", "A", clock.Now().Add(time.Second))
+	require.True(t, m.Touch("a", clock.Now().Add(time.Minute)))
+	clock.Advance(time.Second)
+	_, err = m.Get(DontWait, "a")
+	require.NoError(t, err)
+}
+
+func TestMaxAgePolicy(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock), WithMaxAgePolicy(time.Minute))
+	v.SetExpiring("a", clock.Now().Add(time.Hour))
+	expiration, ok := v.Expiration()
+	require.True(t, ok)
+	require.Equal(t, clock.Now().Add(time.Minute), expiration, "expiration should be capped")
+
+	v.SetExpiring("b", clock.Now().Add(time.Second))
+	expiration, ok = v.Expiration()
+	require.True(t, ok)
+	require.Equal(t, clock.Now().Add(time.Second), expiration, "shorter expirations should be kept")
+
+	v.Set("c")
+	clock.Advance(time.Minute)
+	require.False(t, v.IsSet(), "values set without an expiration should be capped too")
+}
+
 func TestGetOrSetExpiring(t *testing.T) {
 	numSets := 0
 	v := NewValue[string]()
@@ -227,8 +431,399 @@ func BenchmarkGet(b *testing.B) {
 	v.Set("foo")
 	ctx := context.Background()
 
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		v.Get(ctx)
 	}
 }
+
+func BenchmarkGetDontWaitUnset(b *testing.B) {
+	v := NewValue[string]()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.Get(DontWait)
+	}
+}
+
+func TestGetDoesNotAllocate(t *testing.T) {
+	v := NewValue[string]()
+	allocs := testing.AllocsPerRun(100, func() {
+		v.Get(DontWait)
+	})
+	require.Zero(t, allocs, "Get with expired context on unset value should not allocate")
+
+	v.Set("foo")
+	ctx := context.Background()
+	allocs = testing.AllocsPerRun(100, func() {
+		v.Get(ctx)
+	})
+	require.Zero(t, allocs, "Get on set value should not allocate")
+
+	m := NewMap[string, string]()
+	m.Set("foo", "bar")
+	m.Get(ctx, "foo")
+	allocs = testing.AllocsPerRun(100, func() {
+		m.Get(ctx, "foo")
+	})
+	require.Zero(t, allocs, "Map.Get on set key should not allocate")
+}
+
+func TestWeight(t *testing.T) {
+	v := NewValue[string](WithWeigher(func(s string) int { return len(s) }))
+	require.Zero(t, v.Weight())
+	v.Set("hello")
+	require.Equal(t, 5, v.Weight())
+	v.Reset()
+	require.Zero(t, v.Weight())
+
+	require.Zero(t, NewValue[string]().Weight(), "no weigher means no weight")
+	require.Panics(t, func() {
+		NewValue[int](WithWeigher(func(s string) int { return len(s) }))
+	}, "weigher of the wrong type should be rejected")
+}
+
+func TestFreeze(t *testing.T) {
+	var misuses []error
+	v := NewValue[string](WithMisuseHandler(func(err error) {
+		misuses = append(misuses, err)
+	}))
+	v.Set("final")
+	v.Freeze()
+
+	require.Equal(t, ErrFrozen, v.TrySet("changed"))
+	v.Set("changed")
+	v.SetExpiring("changed", time.Now().Add(time.Minute))
+	v.Reset()
+	require.Equal(t, []error{ErrFrozen, ErrFrozen, ErrFrozen}, misuses, "dropped writes should be reported, TrySet errors shouldn't")
+
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "final", r)
+
+	unfrozen := NewValue[string]()
+	require.NoError(t, unfrozen.TrySet("a"))
+	r, err = unfrozen.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "a", r)
+}
+
+func TestChanged(t *testing.T) {
+	v := NewValue[string]()
+	since := v.Version()
+	require.False(t, v.Changed(since))
+
+	v.Set("a")
+	require.True(t, v.Changed(since))
+	since = v.Version()
+	require.False(t, v.Changed(since))
+
+	v.Reset()
+	require.True(t, v.Changed(since))
+}
+
+func TestWait(t *testing.T) {
+	v := WithDefault[string]("default")
+	require.Equal(t, context.Canceled, v.Wait(DontWait), "default shouldn't count as being set")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		v.Set("a")
+	}()
+	require.NoError(t, v.Wait(context.Background()))
+
+	fail := errors.New("fail")
+	v.SetError(fail)
+	require.Equal(t, fail, v.Wait(context.Background()))
+}
+
+func TestGetAtLeast(t *testing.T) {
+	v := NewValue[string]()
+	v.Set("a")
+	version := v.Version()
+	r, err := v.GetAtLeast(DontWait, version)
+	require.NoError(t, err)
+	require.Equal(t, "a", r)
+
+	_, err = v.GetAtLeast(DontWait, version+1)
+	require.True(t, errors.Is(err, context.Canceled))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		v.Reset()
+		time.Sleep(10 * time.Millisecond)
+		v.Set("b")
+	}()
+	r, err = v.GetAtLeast(context.Background(), version+2)
+	require.NoError(t, err)
+	require.Equal(t, "b", r)
+
+	v.Close()
+	_, err = v.GetAtLeast(context.Background(), v.Version()+1)
+	require.Equal(t, ErrClosed, err)
+}
+
+func TestEqualTo(t *testing.T) {
+	v := NewValue[string]()
+	require.False(t, v.EqualTo(""), "unset value shouldn't equal anything")
+	v.Set("Hello")
+	require.True(t, v.EqualTo("Hello"))
+	require.False(t, v.EqualTo("hello"))
+
+	ci := NewValue[string](WithComparator(strings.EqualFold))
+	ci.Set("Hello")
+	require.True(t, ci.EqualTo("hello"), "configured comparator should be used")
+}
+
+func TestSetIfAbsent(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[int](WithClock(clock))
+	require.True(t, v.SetIfAbsent(1))
+	require.False(t, v.SetIfAbsent(2), "second writer should lose")
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, 1, r)
+
+	v.SetExpiring(3, clock.Now().Add(time.Second))
+	clock.Advance(time.Second)
+	require.True(t, v.SetIfAbsent(4), "expired value should count as absent")
+
+	m := NewMap[string, int]()
+	require.True(t, m.SetIfAbsent("a", 1))
+	require.False(t, m.SetIfAbsent("a", 2))
+}
+
+func TestUpdate(t *testing.T) {
+	v := NewValue[int]()
+	increment := func(current int, set bool) int {
+		if !set {
+			return 1
+		}
+		return current + 1
+	}
+
+	errs := make(chan error)
+	go func() {
+		_, err := v.Get(context.Background())
+		errs <- err
+	}()
+	require.Eventually(t, func() bool { return v.Waiters() == 1 }, time.Second, time.Millisecond)
+	v.Update(increment)
+	require.NoError(t, <-errs, "waiters should be notified when Update sets the value")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v.Update(increment)
+		}()
+	}
+	wg.Wait()
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, 101, r, "no updates should be lost")
+}
+
+func TestUpdateKeepsExpiration(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[int](WithClock(clock))
+	expiration := clock.Now().Add(time.Second)
+	v.SetExpiring(1, expiration)
+	v.Update(func(current int, set bool) int { return current * 10 })
+	r, ok := v.Expiration()
+	require.True(t, ok)
+	require.Equal(t, expiration, r)
+}
+
+func TestCompareAndSet(t *testing.T) {
+	v := NewValue[int]()
+	require.False(t, v.CompareAndSet(0, 1), "unset value shouldn't match")
+
+	v.Set(1)
+	require.False(t, v.CompareAndSet(2, 3))
+	require.True(t, v.CompareAndSet(1, 2))
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, 2, r)
+
+	var wg sync.WaitGroup
+	var wins int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if v.CompareAndSet(2, 100+i) {
+				atomic.AddInt32(&wins, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.EqualValues(t, 1, wins, "only one racing CompareAndSet should win")
+}
+
+func TestWithFallback(t *testing.T) {
+	lastKnownGood := NewValue[string]()
+	secondary := NewValue[string]()
+	v := WithDefault("default", WithFallback(lastKnownGood), WithFallback(secondary))
+
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "default", r, "default should be used when no fallback is available")
+
+	secondary.Set("secondary")
+	r, err = v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "secondary", r)
+
+	lastKnownGood.Set("from disk")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	r, err = v.Get(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "from disk", r, "fallbacks should be consulted in order")
+
+	v.Set("real")
+	r, err = v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "real", r)
+
+	noDefault := NewValue[string](WithFallback(NewValue[string]()))
+	_, err = noDefault.Get(DontWait)
+	require.Error(t, err)
+}
+
+func TestLastError(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	err, at := v.LastError()
+	require.NoError(t, err)
+	require.True(t, at.IsZero())
+
+	failure := errors.New("backend unavailable")
+	v.GetOrSetExpiring(clock.Now().Add(time.Minute), func() (string, error) {
+		return "", failure
+	})
+	err, at = v.LastError()
+	require.Equal(t, failure, err)
+	require.Equal(t, clock.Now(), at)
+
+	v.GetOrSetExpiring(clock.Now().Add(time.Minute), func() (string, error) {
+		return "ok", nil
+	})
+	err, _ = v.LastError()
+	require.NoError(t, err, "successful getter should clear the last error")
+}
+
+func TestGetAfterExpiry(t *testing.T) {
+	v := NewValue[string]()
+	v.SetExpiring("stale", time.Now().Add(-time.Second))
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		v.Set("fresh")
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	r, err := v.Get(ctx)
+	require.NoError(t, err, "waiter on expired value should be woken by the next Set")
+	require.Equal(t, "fresh", r)
+}
+
+func TestGetWithBudget(t *testing.T) {
+	v := NewValue[string]()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := v.GetWithBudget(ctx, 80*time.Millisecond)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	elapsed := time.Since(start)
+	require.True(t, elapsed < 60*time.Millisecond, "should have stopped waiting early")
+	require.NoError(t, ctx.Err(), "caller should still have time left")
+
+	_, err = v.GetWithBudget(ctx, time.Hour)
+	require.True(t, errors.Is(err, context.DeadlineExceeded), "exhausted budget should not wait at all")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		v.Set("a")
+	}()
+	r, err := v.GetWithBudget(context.Background(), time.Hour)
+	require.NoError(t, err, "context without deadline should wait as usual")
+	require.Equal(t, "a", r)
+
+	m := NewMap[string, string]()
+	m.Set("a", "b")
+	r, err = m.GetWithBudget(ctx, "a", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, "b", r)
+}
+
+func TestWaiters(t *testing.T) {
+	v := NewValue[string]()
+	require.Zero(t, v.Waiters())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v.Get(ctx)
+		}()
+	}
+	require.Eventually(t, func() bool { return v.Waiters() == 3 }, time.Second, time.Millisecond)
+	cancel()
+	wg.Wait()
+	require.Zero(t, v.Waiters(), "waiters that gave up should be removed")
+}
+
+func TestSetDoesNotBlockOnWaiters(t *testing.T) {
+	v := NewValue[string]().(*value[string])
+	// Simulate a waiter whose channel can't accept the value
+	v.waiters = map[*waiter[string]]struct{}{{ch: make(chan outcome[string])}: {}}
+
+	done := make(chan struct{})
+	go func() {
+		v.Set("a")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Set blocked on a waiter")
+	}
+	require.Zero(t, v.Waiters())
+}
+
+func TestValueString(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	require.Equal(t, "<unset>", v.(fmt.Stringer).String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go v.Get(ctx)
+	require.Eventually(t, func() bool { return v.Waiters() == 1 }, time.Second, time.Millisecond)
+	require.Equal(t, "<unset> (1 waiters)", fmt.Sprint(v))
+	cancel()
+	require.Eventually(t, func() bool { return v.Waiters() == 0 }, time.Second, time.Millisecond)
+
+	v.SetExpiring("hunter2", clock.Now().Add(5*time.Second))
+	require.Equal(t, "string(len=7, hash=f181ec53) (expires in 5s)", fmt.Sprint(v))
+	require.NotContains(t, fmt.Sprint(v), "hunter2", "payload shouldn't be shown by default")
+
+	visible := NewValue[string](WithClock(clock), WithVisiblePayloads())
+	visible.SetExpiring("hello", clock.Now().Add(5*time.Second))
+	require.Equal(t, "hello (expires in 5s)", fmt.Sprint(visible))
+	visible.Set(strings.Repeat("x", 100))
+	require.Equal(t, strings.Repeat("x", 64)+"...", fmt.Sprint(visible), "long payloads should be abbreviated")
+
+	v.SetExpiring("hello", clock.Now().Add(time.Second))
+	clock.Advance(3 * time.Second)
+	require.Equal(t, "<expired 2s ago>", fmt.Sprint(v))
+	v.SetError(errors.New("boom"))
+	require.Equal(t, "<failed: boom>", fmt.Sprint(v))
+}
@@ -0,0 +1,26 @@
+// Package eventualtest provides helpers for testing code that uses package eventual.
+package eventualtest
+
+import (
+	"fmt"
+
+	"github.com/getlantern/eventual/v3"
+	"github.com/getlantern/eventual/v3/internal/testhooks"
+)
+
+// Expire makes the current value of v expire immediately, as if its expiration had passed, without
+// sleeping or injecting a Clock. It does nothing if v isn't set. It panics if v wasn't created by
+// package eventual.
+func Expire[V comparable](v eventual.Value[V]) {
+	if !testhooks.Expire(v) {
+		panic(fmt.Sprintf("eventualtest: can't expire a %T", v))
+	}
+}
+
+// ExpireKey is like Expire, but expires the value at key in m. It does nothing if m isn't tracking
+// key. It panics if m wasn't created by package eventual.
+func ExpireKey[K comparable, V comparable](m eventual.Map[K, V], key K) {
+	if !testhooks.ExpireKey(m, key) {
+		panic(fmt.Sprintf("eventualtest: can't expire keys of a %T", m))
+	}
+}
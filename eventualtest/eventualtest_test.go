@@ -0,0 +1,52 @@
+package eventualtest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/getlantern/eventual/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpire(t *testing.T) {
+	v := eventual.NewValue[string]()
+	Expire(v)
+	require.False(t, v.IsSet())
+
+	v.Set("a")
+	expiry := v.NotifyExpiry()
+	Expire(v)
+	require.True(t, v.IsExpired())
+	_, err := v.Get(eventual.DontWait)
+	require.True(t, errors.Is(err, eventual.ErrExpired))
+	<-expiry
+
+	v.Set("b")
+	require.True(t, v.IsSet(), "expired value should be settable again")
+}
+
+func TestExpireKey(t *testing.T) {
+	m := eventual.NewMap[string, int]()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	ExpireKey(m, "a")
+	ExpireKey(m, "unknown")
+
+	_, err := m.Get(eventual.DontWait, "a")
+	require.True(t, errors.Is(err, eventual.ErrExpired))
+	b, err := m.Get(eventual.DontWait, "b")
+	require.NoError(t, err)
+	require.Equal(t, 2, b)
+	require.Equal(t, 2, m.Stats().Keys, "ExpireKey shouldn't track unknown keys")
+}
+
+// wrappedMap is a Map that wasn't created by package eventual.
+type wrappedMap struct {
+	eventual.Map[string, int]
+}
+
+func TestExpireKeyUnsupported(t *testing.T) {
+	m := wrappedMap{eventual.NewMap[string, int]()}
+	m.Set("a", 1)
+	require.Panics(t, func() { ExpireKey[string, int](m, "a") })
+}
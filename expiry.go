@@ -0,0 +1,100 @@
+package eventual
+
+func (v *value[V]) NotifyExpiry() <-chan struct{} {
+	v.m.Lock()
+	defer v.m.Unlock()
+	if !v.snapshot().valid(v.clock.Now()) {
+		ch := make(chan struct{})
+		close(ch)
+		return ch
+	}
+	if v.expiry == nil {
+		v.expiry = make(chan struct{})
+		v.scheduleExpiry()
+	}
+	return v.expiry
+}
+
+// scheduleExpiry (re)schedules closing the expiry channel when the current value expires. Must be
+// called while holding the mutex.
+func (v *value[V]) scheduleExpiry() {
+	if v.expiryTimer != nil {
+		v.expiryTimer.Stop()
+	}
+	ch := v.expiry
+	v.expiryTimer = v.clock.AfterFunc(v.expiration.Sub(v.clock.Now()), func() {
+		v.m.Lock()
+		defer v.m.Unlock()
+		if v.expiry == ch && v.pins == 0 {
+			v.endExpiry()
+		}
+	})
+}
+
+// endExpiry closes the expiry channel, if any, because the current value is no longer current.
+// Must be called while holding the mutex.
+func (v *value[V]) endExpiry() {
+	if v.expiry == nil {
+		return
+	}
+	close(v.expiry)
+	v.expiry = nil
+	v.expiryTimer.Stop()
+	v.expiryTimer = nil
+}
+
+// lapse is a scheduled call of the OnExpire callbacks, and report of an UpdateExpire, for the
+// current value.
+type lapse struct {
+	timer Timer
+}
+
+func (v *value[V]) OnExpire(fn func(V)) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	v.expireFns = append(v.expireFns, fn)
+	if v.lapse == nil {
+		v.scheduleLapse()
+	}
+}
+
+// scheduleLapse (re)schedules calling the OnExpire callbacks and reporting an UpdateExpire when the
+// current value expires, if there's anyone to tell. Must be called while holding the mutex.
+func (v *value[V]) scheduleLapse() {
+	v.stopLapse()
+	if v.snapshot() == nil || v.expiration.IsZero() {
+		return
+	}
+	if len(v.expireFns) == 0 && v.audit == nil && (v.watched == nil || !v.watched()) {
+		return
+	}
+	l := &lapse{}
+	v.lapse = l
+	l.timer = v.clock.AfterFunc(v.expiration.Sub(v.clock.Now()), func() {
+		v.m.Lock()
+		if v.lapse != l || v.pins > 0 {
+			// The value was replaced, Reset or Touched in the meantime, or it's pinned, in which
+			// case unpinning it reschedules this
+			v.m.Unlock()
+			return
+		}
+		v.lapse = nil
+		i, fns := v.v, v.expireFns
+		v.emit(Update[V]{Kind: UpdateExpire, Time: v.clock.Now(), Old: v.previous()})
+		v.m.Unlock()
+		for _, fn := range fns {
+			// Each in its own goroutine, so that a slow callback doesn't hold up the others
+			go fn(i)
+		}
+	})
+}
+
+// stopLapse cancels calling the OnExpire callbacks, because the current value is no longer
+// current. Must be called while holding the mutex.
+func (v *value[V]) stopLapse() {
+	if v.lapse == nil {
+		return
+	}
+	v.lapse.timer.Stop()
+	v.lapse = nil
+}
@@ -0,0 +1,105 @@
+package eventual
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func requireClosed(t *testing.T, ch <-chan struct{}, msg string) {
+	t.Helper()
+	select {
+	case <-ch:
+	default:
+		t.Fatal(msg)
+	}
+}
+
+func requireOpen(t *testing.T, ch <-chan struct{}, msg string) {
+	t.Helper()
+	select {
+	case <-ch:
+		t.Fatal(msg)
+	default:
+	}
+}
+
+func TestNotifyExpiry(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	requireClosed(t, v.NotifyExpiry(), "unset value should be expired already")
+
+	v.SetExpiring("a", clock.Now().Add(time.Second))
+	expiry := v.NotifyExpiry()
+	require.Equal(t, expiry, v.NotifyExpiry(), "the current value should have one channel")
+	requireOpen(t, expiry, "value hasn't expired yet")
+	clock.Advance(time.Second)
+	requireClosed(t, expiry, "channel should be closed on expiry")
+
+	v.Set("b")
+	expiry = v.NotifyExpiry()
+	v.Reset()
+	requireClosed(t, expiry, "channel should be closed on Reset")
+
+	v.Set("c")
+	expiry = v.NotifyExpiry()
+	v.Set("d")
+	requireClosed(t, expiry, "channel should be closed when the value is replaced")
+}
+
+func TestNotifyExpiryAfterTouch(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	v.SetExpiring("a", clock.Now().Add(time.Second))
+	expiry := v.NotifyExpiry()
+	v.Touch(clock.Now().Add(time.Minute))
+	clock.Advance(time.Second)
+	requireOpen(t, expiry, "touched value shouldn't expire at its old expiration")
+	clock.Advance(time.Minute)
+	requireClosed(t, expiry, "touched value should expire at its new expiration")
+}
+
+func TestOnExpire(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	expired := make(chan string, 10)
+	v.OnExpire(func(i string) { expired <- i })
+
+	v.SetExpiring("a", clock.Now().Add(time.Second))
+	v.SetExpiring("b", clock.Now().Add(time.Second))
+	clock.Advance(500 * time.Millisecond)
+	require.True(t, v.Touch(clock.Now().Add(time.Second)))
+	clock.Advance(500 * time.Millisecond)
+	require.Empty(t, expired, "touched value shouldn't lapse at its old expiration")
+	clock.Advance(500 * time.Millisecond)
+	require.Equal(t, "b", <-expired)
+
+	v.SetExpiring("c", clock.Now().Add(time.Second))
+	v.Reset()
+	v.SetExpiring("d", clock.Now().Add(time.Second))
+	v.SetError(errors.New("fail"))
+	clock.Advance(time.Second)
+	require.Eventually(t, func() bool { return clock.pending() == 0 }, time.Second, time.Millisecond)
+	require.Empty(t, expired, "values that were Reset or failed didn't lapse")
+}
+
+func TestOnExpireConcurrent(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	release := make(chan struct{})
+	defer close(release)
+	expired := make(chan string, 1)
+	v.OnExpire(func(string) { <-release })
+	v.OnExpire(func(i string) { expired <- i })
+
+	v.SetExpiring("a", clock.Now().Add(time.Second))
+	clock.Advance(time.Second)
+	select {
+	case i := <-expired:
+		require.Equal(t, "a", i)
+	case <-time.After(time.Second):
+		t.Fatal("slow callback shouldn't hold up the others")
+	}
+}
@@ -0,0 +1,174 @@
+package eventual
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// exportMagic identifies (and versions) the format written by Map.Export.
+const exportMagic = "EVM1"
+
+// maxExportedFieldSize bounds the length prefixes accepted by Map.Import, so that corrupt input
+// can't make it allocate arbitrarily large buffers.
+const maxExportedFieldSize = 1 << 30
+
+// ErrBadExport is returned by Map.Import when the input isn't in the format written by Map.Export.
+var ErrBadExport = errors.New("eventual: input isn't a Map export")
+
+// WithCodec configures the Codec a Map uses to encode values in Export and decode them in Import.
+// It must be a Codec for the Map's value type. Defaults to JSONCodec.
+func WithCodec[V any](codec Codec[V]) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+// WithKeyCodec configures the Codec a Map uses to encode keys in Export and decode them in Import.
+// It must be a Codec for the Map's key type. Defaults to JSONCodec.
+func WithKeyCodec[K any](codec Codec[K]) Option {
+	return func(o *options) {
+		o.keyCodec = codec
+	}
+}
+
+// Export writes every key that currently holds an unexpired value to w, along with its value and
+// expiration. Entries are streamed one at a time, each encoded with the configured codecs (see
+// WithKeyCodec and WithCodec) and length-prefixed, so exporting doesn't require the whole Map to be
+// serialized in memory.
+func (m *emap[K, V]) Export(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(exportMagic); err != nil {
+		return err
+	}
+
+	var buf [binary.MaxVarintLen64]byte
+	writeField := func(data []byte) error {
+		n := binary.PutUvarint(buf[:], uint64(len(data)))
+		if _, err := bw.Write(buf[:n]); err != nil {
+			return err
+		}
+		_, err := bw.Write(data)
+		return err
+	}
+
+	for _, s := range m.shards {
+		// Only hold the shard's lock long enough to find its values
+		s.mx.Lock()
+		keys := make([]K, 0, len(s.m))
+		values := make([]*value[V], 0, len(s.m))
+		for key, v := range s.m {
+			keys = append(keys, key)
+			values = append(values, v)
+		}
+		s.mx.Unlock()
+
+		for i, v := range values {
+			snap := v.snapshot()
+			if !snap.valid(m.clock.Now()) {
+				continue
+			}
+			keyData, err := m.keyCodec.Encode(keys[i])
+			if err != nil {
+				return fmt.Errorf("eventual: unable to encode key %v: %w", keys[i], err)
+			}
+			valueData, err := m.codec.Encode(snap.v)
+			if err != nil {
+				return fmt.Errorf("eventual: unable to encode value at %v: %w", keys[i], err)
+			}
+			if err := writeField(keyData); err != nil {
+				return err
+			}
+			if err := writeField(valueData); err != nil {
+				return err
+			}
+			n := binary.PutVarint(buf[:], exportedExpiration(snap.expiration))
+			if _, err := bw.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// Import reads entries written by Export from r and sets them in the Map with their original
+// expirations. Entries that have expired since they were exported are skipped.
+func (m *emap[K, V]) Import(r io.Reader) error {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(exportMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != exportMagic {
+		return ErrBadExport
+	}
+
+	readField := func() ([]byte, error) {
+		size, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		if size > maxExportedFieldSize {
+			return nil, ErrBadExport
+		}
+		data := make([]byte, size)
+		_, err = io.ReadFull(br, data)
+		return data, err
+	}
+
+	for {
+		keyData, err := readField()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		valueData, err := readField()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		expiration, err := binary.ReadVarint(br)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+
+		key, err := m.keyCodec.Decode(keyData)
+		if err != nil {
+			return fmt.Errorf("eventual: unable to decode key: %w", err)
+		}
+		value, err := m.codec.Decode(valueData)
+		if err != nil {
+			return fmt.Errorf("eventual: unable to decode value at %v: %w", key, err)
+		}
+		t := importedExpiration(expiration)
+		if !t.IsZero() && !t.After(m.clock.Now()) {
+			continue
+		}
+		m.SetExpiring(key, value, t)
+	}
+}
+
+// exportedExpiration encodes t for Export as nanoseconds since the Unix epoch, or 0 for the zero
+// time, which means that the value never expires.
+func exportedExpiration(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// importedExpiration decodes an expiration encoded by exportedExpiration.
+func importedExpiration(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
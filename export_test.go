@@ -0,0 +1,75 @@
+package eventual
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImport(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMap[string, int](WithClock(clock))
+	m.Set("a", 1)
+	m.SetExpiring("b", 2, clock.Now().Add(time.Minute))
+	m.SetExpiring("expiresSoon", 3, clock.Now().Add(time.Second))
+	m.SetExpiring("expired", 4, clock.Now().Add(-time.Second))
+	m.Reset("unset")
+
+	var buf bytes.Buffer
+	require.NoError(t, m.Export(&buf))
+
+	clock.Advance(2 * time.Second)
+	imported := NewMap[string, int](WithClock(clock))
+	require.NoError(t, imported.Import(bytes.NewReader(buf.Bytes())))
+	require.Equal(t, 2, imported.Stats().Keys, "only live entries should be imported")
+	r, err := imported.Get(DontWait, "a")
+	require.NoError(t, err)
+	require.Equal(t, 1, r)
+	r, err = imported.Get(DontWait, "b")
+	require.NoError(t, err)
+	require.Equal(t, 2, r)
+
+	clock.Advance(time.Minute)
+	_, err = imported.Get(DontWait, "b")
+	require.Error(t, err, "imported entries should keep their expiration")
+
+	require.Equal(t, ErrBadExport, imported.Import(bytes.NewReader([]byte("nope"))))
+	require.Equal(t, io.ErrUnexpectedEOF, imported.Import(bytes.NewReader(buf.Bytes()[:buf.Len()-1])))
+}
+
+func TestExportImportNeverExpires(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMap[string, int](WithClock(clock))
+	m.SetExpiring("forever", 1, time.Time{})
+
+	var buf bytes.Buffer
+	require.NoError(t, m.Export(&buf))
+	imported := NewMap[string, int](WithClock(clock))
+	require.NoError(t, imported.Import(bytes.NewReader(buf.Bytes())))
+	r, err := imported.Get(DontWait, "forever")
+	require.NoError(t, err, "value that never expires should be imported")
+	require.Equal(t, 1, r)
+	expiration, _ := imported.(*emap[string, int]).getValue("forever").Expiration()
+	require.True(t, expiration.IsZero(), "imported value shouldn't get an expiration")
+}
+
+func TestExportCodec(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 16)
+	codec, err := NewAESGCMCodec[string](key, JSONCodec[string]{})
+	require.NoError(t, err)
+
+	m := NewMap[string, string](WithCodec(codec))
+	m.Set("token", "s3cr3t")
+	var buf bytes.Buffer
+	require.NoError(t, m.Export(&buf))
+	require.False(t, bytes.Contains(buf.Bytes(), []byte("s3cr3t")), "values should be encoded with the configured codec")
+
+	imported := NewMap[string, string](WithCodec(codec))
+	require.NoError(t, imported.Import(&buf))
+	r, err := imported.Get(DontWait, "token")
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", r)
+}
@@ -0,0 +1,88 @@
+package eventual
+
+import (
+	"context"
+	"time"
+)
+
+// Fallible is an eventual value whose state may be an error rather than a value, each with its own
+// expiration. It unifies negative caching and SetError: a failed load is remembered for a while,
+// during which Gets return the error immediately instead of waiting or retrying the load.
+type Fallible[V comparable] interface {
+	// SetExpiring sets the value, expiring at the given time.
+	SetExpiring(value V, expiration time.Time)
+
+	// SetErrorExpiring makes the Fallible fail with err until the given expiration, after which it
+	// reverts to being unset. Callers currently waiting receive err. See Value.SetError.
+	SetErrorExpiring(err error, expiration time.Time)
+
+	// Get waits for the value to be set and returns it, or returns the error that the Fallible is
+	// failing with. If the context expires first, an error is returned as in Value.Get.
+	Get(ctx context.Context) (V, error)
+
+	// GetOrLoad returns the stored value or error, or if neither is available, calls load and
+	// stores its result, expiring at valueExpiration if it succeeded or at errorExpiration if it
+	// failed.
+	GetOrLoad(valueExpiration time.Time, errorExpiration time.Time, load func() (V, error)) (V, error)
+
+	// Reset clears the stored value or error.
+	Reset()
+}
+
+// NewFallible creates a new Fallible. The given options are applied as for NewValue.
+func NewFallible[V comparable](opts ...Option) Fallible[V] {
+	return &fallible[V]{value: newValue[V](opts)}
+}
+
+type fallible[V comparable] struct {
+	*value[V]
+}
+
+func (f *fallible[V]) SetErrorExpiring(err error, expiration time.Time) {
+	v := f.value
+	v.m.Lock()
+	if writeErr := v.writable(); writeErr != nil {
+		v.m.Unlock()
+		v.reportMisuse(writeErr)
+		return
+	}
+	v.doSetError(err, expiration)
+	v.m.Unlock()
+}
+
+func (f *fallible[V]) GetOrLoad(valueExpiration time.Time, errorExpiration time.Time, load func() (V, error)) (V, error) {
+	return f.value.getOrLoad(valueExpiration, errorExpiration, load)
+}
+
+// getOrLoad is like GetOrSetExpiring, but also stores load's error, expiring at errorExpiration.
+// While the error is stored, it's returned without calling load.
+func (v *value[V]) getOrLoad(valueExpiration time.Time, errorExpiration time.Time, load func() (V, error)) (V, error) {
+	if s := v.snapshot(); s.valid(v.clock.Now()) {
+		return s.v, nil
+	}
+	if err := v.failed(); err != nil {
+		return v.zeroValue, err
+	}
+
+	v.m.Lock()
+	// Check again in case the value was set or failed while we were waiting for the lock
+	if s := v.snapshot(); s.valid(v.clock.Now()) {
+		v.m.Unlock()
+		return s.v, nil
+	}
+	if err := v.failed(); err != nil {
+		v.m.Unlock()
+		return v.zeroValue, err
+	}
+	return v.load(load, func(i V, err error) (V, error) {
+		if writeErr := v.writable(); writeErr != nil {
+			return i, err
+		}
+		if err != nil {
+			v.doSetError(err, errorExpiration)
+			return v.zeroValue, err
+		}
+		v.doSetExpiring(i, valueExpiration)
+		return i, nil
+	})
+}
@@ -0,0 +1,60 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallible(t *testing.T) {
+	clock := newFakeClock()
+	f := NewFallible[string](WithClock(clock))
+	fail := errors.New("not found")
+
+	loads := 0
+	load := func() (string, error) {
+		loads++
+		if loads == 1 {
+			return "", fail
+		}
+		return "found", nil
+	}
+	_, err := f.GetOrLoad(clock.Now().Add(time.Minute), clock.Now().Add(time.Second), load)
+	require.Equal(t, fail, err)
+	_, err = f.GetOrLoad(clock.Now().Add(time.Minute), clock.Now().Add(time.Second), load)
+	require.Equal(t, fail, err, "error should be cached")
+	_, err = f.Get(context.Background())
+	require.Equal(t, fail, err, "Get should return the cached error without waiting")
+	require.Equal(t, 1, loads)
+
+	clock.Advance(time.Second)
+	r, err := f.GetOrLoad(clock.Now().Add(time.Minute), clock.Now().Add(time.Second), load)
+	require.NoError(t, err, "expired error should be retried")
+	require.Equal(t, "found", r)
+	require.Equal(t, 2, loads)
+
+	r, err = f.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "found", r)
+}
+
+func TestFallibleErrorExpiry(t *testing.T) {
+	clock := newFakeClock()
+	f := NewFallible[string](WithClock(clock))
+	fail := errors.New("fail")
+	f.SetErrorExpiring(fail, clock.Now().Add(time.Second))
+	_, err := f.Get(DontWait)
+	require.Equal(t, fail, err)
+
+	clock.Advance(time.Second)
+	_, err = f.Get(DontWait)
+	require.True(t, errors.Is(err, ErrNotSet), "expired error should revert to unset")
+
+	f.SetErrorExpiring(fail, clock.Now().Add(time.Second))
+	f.Reset()
+	_, err = f.Get(DontWait)
+	require.True(t, errors.Is(err, ErrNotSet))
+}
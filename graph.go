@@ -0,0 +1,200 @@
+package eventual
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// graphMx guards the dependents of every node.
+var graphMx sync.RWMutex
+
+// node is a Value's place in the graph of derived Values.
+type node struct {
+	// dependents are the nodes of the Values derived directly from this one. Guarded by graphMx.
+	dependents    []*node
+	hasDependents atomic.Bool
+	// recompute updates a derived Value from its upstreams. It's nil for Values that aren't
+	// derived.
+	recompute func()
+	// recomputing is true while recompute is running. It's only accessed while holding the
+	// derived Value's mutex.
+	recomputing bool
+}
+
+// graphNode is implemented by every Value created by this package.
+type graphNode[V comparable] interface {
+	graphValue() *value[V]
+}
+
+func (v *value[V]) graphValue() *value[V] {
+	return v
+}
+
+// upstream returns the value underlying a Value that's passed to Transform or Combine.
+func upstream[V comparable](v Value[V]) *value[V] {
+	n, ok := v.(graphNode[V])
+	if !ok {
+		panic("eventual: values can only be derived from Values created by this package")
+	}
+	return n.graphValue()
+}
+
+// Transform returns a Value that's derived from u by applying fn to its value. Whenever u is Set,
+// Reset, fails or is closed, the derived Value is updated to match, along with everything derived
+// from it in turn: Values are recomputed in topological order, so each one is recomputed once,
+// after all of its upstreams. The derived Value expires along with u. While u fails, the derived
+// Value fails with an error that wraps u's, so that errors.Is and errors.As see the cause. fn is
+// called while holding the derived Value's lock, so it must not access the derived Value.
+func Transform[U comparable, V comparable](u Value[U], fn func(U) V, opts ...Option) Value[V] {
+	up := upstream(u)
+	d := newValue[V](opts)
+	d.node.recompute = func() {
+		d.recompute(func() (*snapshot[V], *failure) {
+			s, f := up.current()
+			if s == nil {
+				return nil, upstreamFailure(up, f)
+			}
+			return &snapshot[V]{v: fn(s.v), expiration: s.expiration, softExpiration: s.softExpiration, pinned: s.pinned}, nil
+		})
+	}
+	depend(&d.node, &up.node)
+	d.node.recompute()
+	return d
+}
+
+// Combine is like Transform, but derives a Value from two upstreams. The derived Value is set
+// while both upstreams are set, fails while either of them fails and expires when either of them
+// expires.
+func Combine[A comparable, B comparable, V comparable](a Value[A], b Value[B], fn func(A, B) V, opts ...Option) Value[V] {
+	upA, upB := upstream(a), upstream(b)
+	d := newValue[V](opts)
+	d.node.recompute = func() {
+		d.recompute(func() (*snapshot[V], *failure) {
+			sa, fa := upA.current()
+			sb, fb := upB.current()
+			switch {
+			case fa != nil:
+				return nil, upstreamFailure(upA, fa)
+			case fb != nil:
+				return nil, upstreamFailure(upB, fb)
+			case sa == nil || sb == nil:
+				return nil, nil
+			}
+			return &snapshot[V]{
+				v:              fn(sa.v, sb.v),
+				expiration:     earliest(sa.unpinnedExpiration(), sb.unpinnedExpiration()),
+				softExpiration: earliest(sa.softExpiration, sb.softExpiration),
+			}, nil
+		})
+	}
+	depend(&d.node, &upA.node, &upB.node)
+	d.node.recompute()
+	return d
+}
+
+// current returns the current snapshot if it's valid, and otherwise the failure, if any.
+func (v *value[V]) current() (*snapshot[V], *failure) {
+	if s := v.snapshot(); s.valid(v.clock.Now()) {
+		return s, nil
+	}
+	if err := v.failed(); err != nil {
+		return nil, v.failure.Load()
+	}
+	return nil, nil
+}
+
+// upstreamFailure wraps f, the failure of up, for a Value derived from up. Failures of Values that
+// are derived themselves already wrap their upstream's, so they're passed on as is.
+func upstreamFailure[U comparable](up *value[U], f *failure) *failure {
+	if f == nil || up.node.recompute != nil {
+		return f
+	}
+	return &failure{err: fmt.Errorf("eventual: upstream failed: %w", f.err), expiration: f.expiration}
+}
+
+// recompute updates a derived value with the result of compute, which returns the new value and
+// its expirations if the upstreams are set, and otherwise the upstreams' failure, if any.
+func (v *value[V]) recompute(compute func() (*snapshot[V], *failure)) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	if v.writable() != nil {
+		return
+	}
+	v.node.recomputing = true
+	defer func() { v.node.recomputing = false }()
+	s, f := compute()
+	switch {
+	case s != nil:
+		v.doSet(s.v, s.softExpiration, s.expiration, Provenance{})
+	case f != nil:
+		v.doSetError(f.err, f.expiration)
+	case v.snapshot() != nil || v.failure.Load() != nil:
+		v.doReset()
+	}
+}
+
+// depend records that d is derived from upstreams.
+func depend(d *node, upstreams ...*node) {
+	graphMx.Lock()
+	defer graphMx.Unlock()
+	for _, u := range upstreams {
+		u.dependents = append(u.dependents, d)
+		u.hasDependents.Store(true)
+	}
+}
+
+// propagate recomputes the Values derived from this one, in topological order. Values that are
+// being recomputed don't propagate, because the Value that started the propagation already takes
+// care of everything downstream. Must be called while holding the mutex.
+func (v *value[V]) propagate() {
+	if !v.node.hasDependents.Load() || v.node.recomputing {
+		return
+	}
+	graphMx.RLock()
+	order := v.node.downstream()
+	graphMx.RUnlock()
+	for _, n := range order {
+		n.recompute()
+	}
+}
+
+// downstream returns the nodes that are transitively derived from n, in topological order. Must be
+// called while holding graphMx.
+func (n *node) downstream() []*node {
+	visited := make(map[*node]bool)
+	var postorder []*node
+	var visit func(n *node)
+	visit = func(n *node) {
+		for _, d := range n.dependents {
+			if !visited[d] {
+				visited[d] = true
+				visit(d)
+				postorder = append(postorder, d)
+			}
+		}
+	}
+	visit(n)
+	for i, j := 0, len(postorder)-1; i < j; i, j = i+1, j-1 {
+		postorder[i], postorder[j] = postorder[j], postorder[i]
+	}
+	return postorder
+}
+
+// earliest returns the earlier of two expirations, where the zero time means no expiration.
+// unpinnedExpiration returns when s expires, or the zero time if it's pinned and therefore doesn't
+// expire.
+func (s *snapshot[V]) unpinnedExpiration() time.Time {
+	if s.pinned {
+		return time.Time{}
+	}
+	return s.expiration
+}
+
+func earliest(a time.Time, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,104 @@
+package eventual
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransform(t *testing.T) {
+	clock := newFakeClock()
+	u := NewValue[int](WithClock(clock))
+	d := Transform(u, strconv.Itoa, WithClock(clock))
+	_, err := d.Get(DontWait)
+	require.True(t, errors.Is(err, ErrNotSet))
+
+	u.SetExpiring(1, clock.Now().Add(time.Minute))
+	r, err := d.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "1", r)
+	expiration, _ := d.Expiration()
+	require.Equal(t, clock.Now().Add(time.Minute), expiration, "derived value should expire with its upstream")
+
+	require.True(t, u.Touch(clock.Now().Add(time.Hour)))
+	expiration, _ = d.Expiration()
+	require.Equal(t, clock.Now().Add(time.Hour), expiration)
+
+	fail := errors.New("fail")
+	u.SetError(fail)
+	_, err = d.Get(DontWait)
+	require.EqualError(t, err, "eventual: upstream failed: fail")
+	require.True(t, errors.Is(err, fail), "upstream's error should be wrapped")
+
+	u.Reset()
+	_, err = d.Get(DontWait)
+	require.True(t, errors.Is(err, ErrNotSet))
+
+	u.Close()
+	_, err = d.Get(DontWait)
+	require.True(t, errors.Is(err, ErrClosed))
+}
+
+func TestCombineDiamond(t *testing.T) {
+	// a feeds b and c, which both feed d
+	a := NewValue[int]()
+	b := Transform(a, func(i int) int { return i * 10 })
+	c := Transform(a, func(i int) int { return i * 100 })
+	var computed []int
+	d := Combine(b, c, func(b int, c int) int {
+		computed = append(computed, b+c)
+		return b + c
+	})
+	a.Set(1)
+	r, err := d.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, 110, r)
+	require.Equal(t, []int{110}, computed, "d should be computed once, after both b and c")
+
+	a.Set(2)
+	r, _ = d.Get(DontWait)
+	require.Equal(t, 220, r)
+	require.Equal(t, []int{110, 220}, computed)
+
+	a.Reset()
+	_, err = d.Get(DontWait)
+	require.True(t, errors.Is(err, ErrNotSet))
+
+	b2 := NewValue[int]()
+	e := Combine(d, b2, func(d int, b2 int) int { return d + b2 })
+	a.Set(3)
+	_, err = e.Get(DontWait)
+	require.Error(t, err, "e shouldn't be set before both upstreams are")
+	b2.Set(4)
+	r, err = e.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, 334, r)
+}
+
+type upstreamError struct {
+	code int
+}
+
+func (e *upstreamError) Error() string {
+	return "code " + strconv.Itoa(e.code)
+}
+
+func TestCombineWrapsUpstreamError(t *testing.T) {
+	a := NewValue[int]()
+	b := NewValue[int]()
+	c := Combine(a, b, func(a int, b int) int { return a + b })
+	d := Transform(c, strconv.Itoa)
+	a.Set(1)
+	b.SetError(&upstreamError{code: 503})
+
+	_, err := c.Get(DontWait)
+	require.EqualError(t, err, "eventual: upstream failed: code 503")
+	_, err = d.Get(DontWait)
+	require.EqualError(t, err, "eventual: upstream failed: code 503", "error shouldn't be wrapped again further downstream")
+	var target *upstreamError
+	require.True(t, errors.As(err, &target))
+	require.Equal(t, 503, target.code)
+}
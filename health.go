@@ -0,0 +1,70 @@
+package eventual
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Checker is implemented by things that can report on their own health, such as Values, Maps and
+// Refreshing Values. Its shape matches the checks that common health check frameworks accept, so
+// that these can be registered with them directly. Check returns nil if healthy, and otherwise an
+// error describing the problem.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+func (v *value[V]) Check(context.Context) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+	return v.unavailable()
+}
+
+// unavailable returns why the Value doesn't hold a valid value right now, or nil if it does. It
+// must be called while holding the lock.
+func (v *value[V]) unavailable() error {
+	s := v.snapshot()
+	if s.valid(v.clock.Now()) {
+		return nil
+	}
+	if v.closed.Load() {
+		return ErrClosed
+	}
+	if err := v.failed(); err != nil {
+		return err
+	}
+	if s != nil {
+		return ErrExpired
+	}
+	return ErrNotSet
+}
+
+func (r *refreshing[V]) Check(ctx context.Context) error {
+	if err, _ := r.LastError(); err != nil {
+		return fmt.Errorf("eventual: last refresh failed: %w", err)
+	}
+	return r.value.Check(ctx)
+}
+
+// WithMaxPendingKeys configures a Map to report itself as unhealthy from Check while more than
+// maxPending keys have callers waiting for a value (see Map.ForEachPending). By default, a Map is
+// always healthy.
+func WithMaxPendingKeys(maxPending int) Option {
+	return func(o *options) {
+		o.maxPending = maxPending
+	}
+}
+
+func (m *emap[K, V]) Check(context.Context) error {
+	if m.maxPending <= 0 {
+		return nil
+	}
+	pending := 0
+	m.ForEachPending(func(K, int, time.Duration) {
+		pending++
+	})
+	if pending > m.maxPending {
+		return fmt.Errorf("eventual: %d keys are pending, more than %d", pending, m.maxPending)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueCheck(t *testing.T) {
+	clock := newFakeClock()
+	v := WithDefault("default", WithClock(clock))
+	var c Checker = v
+	require.True(t, errors.Is(c.Check(context.Background()), ErrNotSet), "default shouldn't count")
+
+	v.SetExpiring("a", clock.Now().Add(time.Second))
+	require.NoError(t, c.Check(context.Background()))
+	clock.Advance(2 * time.Second)
+	require.True(t, errors.Is(c.Check(context.Background()), ErrExpired))
+
+	fail := errors.New("fail")
+	v.SetError(fail)
+	require.True(t, errors.Is(c.Check(context.Background()), fail))
+
+	v.Close()
+	require.True(t, errors.Is(c.Check(context.Background()), ErrClosed))
+}
+
+func TestMapCheck(t *testing.T) {
+	m := NewMap[string, int](WithMaxPendingKeys(1))
+	var c Checker = m
+	require.NoError(t, c.Check(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			m.Get(ctx, key)
+		}(key)
+	}
+	require.Eventually(t, func() bool {
+		return c.Check(context.Background()) != nil
+	}, time.Second, time.Millisecond, "more than 1 pending key should be unhealthy")
+
+	m.Set("a", 1)
+	require.NoError(t, c.Check(context.Background()))
+	cancel()
+	wg.Wait()
+
+	require.NoError(t, NewMap[string, int]().Check(context.Background()), "Map without a limit should always be healthy")
+}
+
+func TestRefreshingCheck(t *testing.T) {
+	clock := newFakeClock()
+	fail := errors.New("fail")
+	v := NewRefreshing[int](time.Minute, func(ctx context.Context) (int, error) {
+		return 0, fail
+	}, WithClock(clock))
+	defer v.Stop()
+
+	require.Eventually(t, func() bool {
+		return errors.Is(v.Check(context.Background()), fail)
+	}, time.Second, time.Millisecond, "failed refresh should be unhealthy")
+}
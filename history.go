@@ -0,0 +1,40 @@
+package eventual
+
+import (
+	"time"
+)
+
+// Entry is a value that a Value was set to, as returned by Value.History.
+type Entry[V any] struct {
+	Value V
+	// Time is when the value was set, according to the configured Clock (see WithClock).
+	Time time.Time
+}
+
+// WithHistory makes a Value keep the last n values it was set to, which are available from
+// Value.History. This helps with debugging flapping configuration and with computing deltas.
+// Secrets (see NewSecret) never keep a history.
+func WithHistory(n int) Option {
+	return func(o *options) {
+		o.history = n
+	}
+}
+
+// record adds i to the history, dropping the oldest entry if the history is full. Must be called
+// while holding the mutex.
+func (v *value[V]) record(i V, t time.Time) {
+	if v.historySize <= 0 || v.sensitive {
+		return
+	}
+	if len(v.history) == v.historySize {
+		copy(v.history, v.history[1:])
+		v.history = v.history[:len(v.history)-1]
+	}
+	v.history = append(v.history, Entry[V]{Value: i, Time: t})
+}
+
+func (v *value[V]) History() []Entry[V] {
+	v.m.Lock()
+	defer v.m.Unlock()
+	return append([]Entry[V](nil), v.history...)
+}
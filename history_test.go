@@ -0,0 +1,32 @@
+package eventual
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[int](WithClock(clock), WithHistory(2))
+	require.Empty(t, v.History())
+
+	start := clock.Now()
+	v.Set(1)
+	clock.Advance(time.Second)
+	v.Set(2)
+	clock.Advance(time.Second)
+	v.Reset()
+	v.Set(3)
+	require.Equal(t, []Entry[int]{
+		{Value: 2, Time: start.Add(time.Second)},
+		{Value: 3, Time: start.Add(2 * time.Second)},
+	}, v.History(), "only the last 2 values should be kept")
+
+	require.Empty(t, NewValue[int]().History(), "history should be opt-in")
+
+	secret := NewSecret[int](WithHistory(2))
+	secret.Set(1)
+	require.Empty(t, secret.History(), "secrets shouldn't keep a history")
+}
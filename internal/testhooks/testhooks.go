@@ -0,0 +1,11 @@
+// Package testhooks connects package eventualtest to the internals of package eventual, which
+// installs the hooks when it's initialized.
+package testhooks
+
+// Expire expires the current value of an eventual.Value, reporting whether v is a Value that
+// supports it.
+var Expire func(v interface{}) bool
+
+// ExpireKey expires the current value at key in an eventual.Map, if m is tracking key, reporting
+// whether m is a Map that supports it.
+var ExpireKey func(m interface{}, key interface{}) bool
@@ -0,0 +1,59 @@
+package eventual
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Invalidator is implemented by caches whose entries can be invalidated by key, such as Map.
+type Invalidator[K comparable] interface {
+	// Invalidate drops the entry at key, if any, reporting whether there was one.
+	Invalidate(key K) bool
+}
+
+// InvalidationMetrics counts the invalidations processed by ApplyInvalidations. It's safe to read
+// concurrently.
+type InvalidationMetrics struct {
+	received uint64
+	applied  uint64
+}
+
+// Received returns the number of keys received from the invalidation stream.
+func (m *InvalidationMetrics) Received() uint64 {
+	return atomic.LoadUint64(&m.received)
+}
+
+// Applied returns the number of received keys that invalidated an entry in at least one target.
+func (m *InvalidationMetrics) Applied() uint64 {
+	return atomic.LoadUint64(&m.applied)
+}
+
+// ApplyInvalidations invalidates every key received from stream in each of the targets, for example
+// to keep Maps in sync with an external invalidation bus. It blocks until stream is closed, in
+// which case it returns nil, or ctx is done, in which case it returns the context's error. If
+// metrics isn't nil, it's updated as invalidations are processed.
+func ApplyInvalidations[K comparable](ctx context.Context, stream <-chan K, metrics *InvalidationMetrics, targets ...Invalidator[K]) error {
+	if metrics == nil {
+		metrics = &InvalidationMetrics{}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case key, ok := <-stream:
+			if !ok {
+				return nil
+			}
+			atomic.AddUint64(&metrics.received, 1)
+			applied := false
+			for _, target := range targets {
+				if target.Invalidate(key) {
+					applied = true
+				}
+			}
+			if applied {
+				atomic.AddUint64(&metrics.applied, 1)
+			}
+		}
+	}
+}
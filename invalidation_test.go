@@ -0,0 +1,37 @@
+package eventual
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyInvalidations(t *testing.T) {
+	users := NewMap[string, int]()
+	users.Set("alice", 1)
+	users.Set("bob", 2)
+	orgs := NewMap[string, int]()
+	orgs.Set("alice", 3)
+
+	stream := make(chan string, 3)
+	stream <- "alice"
+	stream <- "carol"
+	stream <- "bob"
+	close(stream)
+
+	var metrics InvalidationMetrics
+	require.NoError(t, ApplyInvalidations[string](context.Background(), stream, &metrics, users, orgs))
+	require.EqualValues(t, 3, metrics.Received())
+	require.EqualValues(t, 2, metrics.Applied(), "keys should be counted once, however many targets they invalidated")
+
+	_, err := users.Get(DontWait, "alice")
+	require.Error(t, err)
+	_, err = orgs.Get(DontWait, "alice")
+	require.Error(t, err)
+	require.Equal(t, 2, users.Stats().Keys, "unknown keys shouldn't be added to the map")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Equal(t, context.Canceled, ApplyInvalidations[string](ctx, make(chan string), nil, users))
+}
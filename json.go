@@ -0,0 +1,60 @@
+package eventual
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// jsonEnvelope is how Values configured with WithJSONExpiration are marshaled.
+type jsonEnvelope[V any] struct {
+	Value      V         `json:"value"`
+	Expiration time.Time `json:"expiration"`
+}
+
+// WithJSONExpiration makes Values marshal to JSON as an object holding both the value and its
+// expiration, such as {"value":42,"expiration":"2024-01-01T00:00:00Z"}, and unmarshal from the
+// same, instead of as just the value. Values stored with Set expire ten years after they were set.
+func WithJSONExpiration() Option {
+	return func(o *options) {
+		o.jsonExpiration = true
+	}
+}
+
+// MarshalJSON implements json.Marshaler, so that structs holding Values can be serialized
+// directly. It marshals the current value, or null if no unexpired value is set. Defaults and
+// fallbacks are ignored. The payload of a secret value (see NewSecret) is redacted.
+func (v *value[V]) MarshalJSON() ([]byte, error) {
+	s := v.snapshot()
+	if !s.valid(v.clock.Now()) {
+		return []byte("null"), nil
+	}
+	if v.sensitive {
+		return json.Marshal(redacted)
+	}
+	if v.jsonExpiration {
+		return json.Marshal(jsonEnvelope[V]{s.v, s.expiration})
+	}
+	return json.Marshal(s.v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It sets the Value to the unmarshaled value right
+// away. By convention, null is a no-op. It returns an error if the Value doesn't accept writes
+// (see TrySet).
+func (v *value[V]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+	if v.jsonExpiration {
+		var envelope jsonEnvelope[V]
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return err
+		}
+		return v.trySetExpiring(envelope.Value, envelope.Expiration)
+	}
+	var i V
+	if err := json.Unmarshal(data, &i); err != nil {
+		return err
+	}
+	return v.TrySet(i)
+}
@@ -0,0 +1,60 @@
+package eventual
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueJSON(t *testing.T) {
+	type status struct {
+		Name  Value[string] `json:"name"`
+		Count Value[int]    `json:"count"`
+	}
+	in := status{Name: NewValue[string](), Count: WithDefault(5)}
+	in.Name.Set("a")
+	data, err := json.Marshal(in)
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"a","count":null}`, string(data), "defaults shouldn't be marshaled")
+
+	out := status{Name: NewValue[string](), Count: NewValue[int]()}
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"b","count":3}`), &out))
+	name, err := out.Name.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "b", name)
+	r, err := out.Count.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, 3, r)
+
+	require.NoError(t, out.Count.UnmarshalJSON([]byte("null")))
+	require.True(t, out.Count.IsSet(), "null should be a no-op")
+
+	frozen := NewValue[int]()
+	frozen.Freeze()
+	require.Equal(t, ErrFrozen, json.Unmarshal([]byte(`1`), frozen))
+
+	secret := NewSecret[string]()
+	secret.Set("hunter2")
+	data, err = json.Marshal(secret)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "hunter2")
+}
+
+func TestValueJSONExpiration(t *testing.T) {
+	clock := newFakeClock()
+	expiration := clock.Now().Add(time.Minute).UTC()
+	v := NewValue[int](WithClock(clock), WithJSONExpiration())
+	v.SetExpiring(1, expiration)
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	out := NewValue[int](WithClock(clock), WithJSONExpiration())
+	require.NoError(t, json.Unmarshal(data, out))
+	r, err := out.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, 1, r)
+	actual, _ := out.Expiration()
+	require.True(t, expiration.Equal(actual), "expiration should round trip")
+}
@@ -0,0 +1,55 @@
+package eventual
+
+import (
+	"fmt"
+)
+
+// Key2 is a composite Map key made of two parts, such as a (tenant, resource) pair. Being
+// comparable, it can be used as a key directly.
+type Key2[A comparable, B comparable] struct {
+	First  A
+	Second B
+}
+
+// NewKey2 creates a Key2.
+func NewKey2[A comparable, B comparable](a A, b B) Key2[A, B] {
+	return Key2[A, B]{First: a, Second: b}
+}
+
+// Prefix returns the first part of the key, for example the tenant.
+func (k Key2[A, B]) Prefix() A {
+	return k.First
+}
+
+// String formats the key as its parts separated by slashes.
+func (k Key2[A, B]) String() string {
+	return fmt.Sprintf("%v/%v", k.First, k.Second)
+}
+
+// Key3 is a composite Map key made of three parts.
+type Key3[A comparable, B comparable, C comparable] struct {
+	First  A
+	Second B
+	Third  C
+}
+
+// NewKey3 creates a Key3.
+func NewKey3[A comparable, B comparable, C comparable](a A, b B, c C) Key3[A, B, C] {
+	return Key3[A, B, C]{First: a, Second: b, Third: c}
+}
+
+// Prefix returns the first two parts of the key.
+func (k Key3[A, B, C]) Prefix() Key2[A, B] {
+	return Key2[A, B]{First: k.First, Second: k.Second}
+}
+
+// String formats the key as its parts separated by slashes.
+func (k Key3[A, B, C]) String() string {
+	return fmt.Sprintf("%v/%v/%v", k.First, k.Second, k.Third)
+}
+
+// Prefixed is implemented by composite keys that can be grouped by a leading part, such as Key2
+// and Key3.
+type Prefixed[P comparable] interface {
+	Prefix() P
+}
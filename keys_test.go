@@ -0,0 +1,32 @@
+package eventual
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompositeKeys(t *testing.T) {
+	m := NewMap[Key2[string, int], string]()
+	m.Set(NewKey2("acme", 1), "one")
+	m.Set(NewKey2("acme", 2), "two")
+
+	r, err := m.Get(DontWait, NewKey2("acme", 2))
+	require.NoError(t, err)
+	require.Equal(t, "two", r)
+	_, err = m.Get(DontWait, NewKey2("other", 2))
+	require.Error(t, err)
+
+	k2 := NewKey2("acme", 1)
+	require.Equal(t, "acme", k2.Prefix())
+	require.Equal(t, "acme/1", k2.String())
+	require.Equal(t, "acme/1", fmt.Sprint(k2))
+
+	k3 := NewKey3("acme", "users", 7)
+	require.Equal(t, NewKey2("acme", "users"), k3.Prefix())
+	require.Equal(t, "acme/users/7", k3.String())
+
+	var p Prefixed[string] = k2
+	require.Equal(t, "acme", p.Prefix())
+}
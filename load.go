@@ -0,0 +1,46 @@
+package eventual
+
+import (
+	"errors"
+)
+
+// errGetterPanicked is returned to callers that were waiting for a getter that panicked.
+var errGetterPanicked = errors.New("eventual: getter panicked")
+
+// call is a getter that's running on behalf of every caller that needs the missing value.
+type call[V comparable] struct {
+	done chan struct{}
+	v    V
+	err  error
+}
+
+// load runs getter to populate the value, making sure that only one getter runs at a time: callers
+// that arrive while one is in flight wait for its result instead. getter runs without holding the
+// mutex, so it never stalls Get or Set. store is called with getter's result while holding the
+// mutex, and returns what load returns to every caller. Must be called while holding the mutex,
+// which load releases.
+func (v *value[V]) load(getter func() (V, error), store func(i V, err error) (V, error)) (V, error) {
+	if c := v.inflight; c != nil {
+		v.m.Unlock()
+		<-c.done
+		return c.v, c.err
+	}
+	c := &call[V]{done: make(chan struct{}), err: errGetterPanicked}
+	v.inflight = c
+	v.beginLoad()
+	v.m.Unlock()
+
+	defer func() {
+		v.m.Lock()
+		v.inflight = nil
+		v.endLoad()
+		v.m.Unlock()
+		close(c.done)
+	}()
+	i, err := getter()
+	v.m.Lock()
+	v.recordError(err)
+	c.v, c.err = store(i, err)
+	v.m.Unlock()
+	return c.v, c.err
+}
@@ -0,0 +1,85 @@
+package eventual
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSingleflight(t *testing.T) {
+	v := NewValue[string]()
+	release := make(chan struct{})
+	var calls int32
+	getter := func() (string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "loaded", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan string, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := v.GetOrSetExpiring(time.Now().Add(time.Minute), getter)
+			require.NoError(t, err)
+			results <- r
+		}()
+	}
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+
+	// Get and Set aren't stalled by the running getter
+	_, err := v.Get(DontWait)
+	require.Error(t, err)
+	v.Set("set")
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "set", r)
+
+	close(release)
+	wg.Wait()
+	close(results)
+	for r := range results {
+		require.True(t, r == "loaded" || r == "set", r)
+	}
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls), "getter should only run once")
+}
+
+func TestLoadPanic(t *testing.T) {
+	v := NewValue[string]()
+	release := make(chan struct{})
+	go func() {
+		defer func() { recover() }()
+		v.GetOrSetExpiring(time.Now().Add(time.Minute), func() (string, error) {
+			<-release
+			panic("boom")
+		})
+	}()
+	require.Eventually(t, func() bool {
+		v := v.(*value[string])
+		v.m.Lock()
+		defer v.m.Unlock()
+		return v.inflight != nil
+	}, time.Second, time.Millisecond)
+
+	waited := make(chan error)
+	go func() {
+		_, err := v.GetOrSetExpiring(time.Now().Add(time.Minute), func() (string, error) {
+			return "unused", nil
+		})
+		waited <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	require.Equal(t, errGetterPanicked, <-waited)
+
+	r, err := v.GetOrSetExpiring(time.Now().Add(time.Minute), func() (string, error) {
+		return "recovered", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "recovered", r)
+}
@@ -0,0 +1,330 @@
+package eventual
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// LoadingMap is a Map that populates keys on demand using a loader function and, optionally, keeps
+// them fresh by reloading them in the background shortly before they expire.
+type LoadingMap[K comparable, V comparable] interface {
+	Map[K, V]
+
+	// Load returns the value at key, calling the loader to populate it if it isn't set or has
+	// expired. If the loader returns an error, it's returned, and cached if the key's NegativeTTL
+	// is positive (see KeyPolicy). Otherwise nothing is stored.
+	Load(ctx context.Context, key K) (V, error)
+
+	// Scoped returns a view of the map for a single request, whose Loads are bound to ctx. The
+	// view remembers every value it loads, so asking for the same key repeatedly within the request
+	// performs at most one load, even if the key expires from the map in the meantime. What the
+	// view remembers is private to it and never added to the map.
+	Scoped(ctx context.Context) Scope[K, V]
+
+	// Stop cancels all scheduled refreshes and any refresh that's currently in flight. The map
+	// remains usable, but values are no longer refreshed in the background.
+	Stop()
+}
+
+// Scope is a request-scoped view of a LoadingMap (see LoadingMap.Scoped).
+type Scope[K comparable, V comparable] interface {
+	// Load is like LoadingMap.Load, but returns the value that the Scope loaded before, if any.
+	// Errors aren't remembered.
+	Load(key K) (V, error)
+}
+
+// LoadingConfig configures a LoadingMap.
+type LoadingConfig[K comparable] struct {
+	// TTL is how long a loaded value remains valid. Defaults to ten years.
+	TTL time.Duration
+
+	// RefreshAhead, if positive, enables refresh-ahead: keys are reloaded in the background this
+	// long before they expire, so that callers never block on the loader for a hot key. Keys whose
+	// TTL isn't longer than RefreshAhead, for example because of their KeyPolicy, are reloaded
+	// halfway through their TTL instead.
+	RefreshAhead time.Duration
+
+	// RefreshJitter spreads refreshes out by starting each one up to this much earlier than
+	// RefreshAhead alone would, so keys loaded at the same time don't refresh in lockstep.
+	RefreshJitter time.Duration
+
+	// MaxConcurrentRefreshes caps the number of background refreshes that run at once. Refreshes
+	// beyond the cap wait for a slot. Zero means no cap.
+	MaxConcurrentRefreshes int
+
+	// LoaderTimeout, if positive, bounds how long each call to the loader may take.
+	LoaderTimeout time.Duration
+
+	// NegativeTTL, if positive, makes Load cache loader errors for this long, during which Load
+	// and Get return the error without calling the loader again.
+	NegativeTTL time.Duration
+
+	// Policy, if set, is called with each key to override the configuration above for that key,
+	// for example because keys are served by backends with different latency and freshness
+	// profiles.
+	Policy func(key K) KeyPolicy
+
+	// OnRefreshSuccess, if set, is called after key was refreshed in the background.
+	OnRefreshSuccess func(key K)
+
+	// OnRefreshFailure, if set, is called when refreshing key in the background failed. The
+	// existing value is kept until it expires.
+	OnRefreshFailure func(key K, err error)
+}
+
+// KeyPolicy overrides a LoadingMap's configuration for a key (see LoadingConfig.Policy). Zero
+// fields keep the LoadingConfig's setting.
+type KeyPolicy struct {
+	// LoaderTimeout overrides LoadingConfig.LoaderTimeout.
+	LoaderTimeout time.Duration
+
+	// TTL overrides LoadingConfig.TTL.
+	TTL time.Duration
+
+	// NegativeTTL overrides LoadingConfig.NegativeTTL.
+	NegativeTTL time.Duration
+
+	// RefreshAhead overrides LoadingConfig.RefreshAhead. A negative RefreshAhead disables
+	// refresh-ahead for the key.
+	RefreshAhead time.Duration
+}
+
+type loadingMap[K comparable, V comparable] struct {
+	*emap[K, V]
+	loader   func(context.Context, K) (V, error)
+	cfg      LoadingConfig[K]
+	ctx      context.Context
+	cancel   context.CancelFunc
+	slots    chan struct{}
+	timersMx sync.Mutex
+	timers   map[K]Timer
+	stopped  bool
+	randMx   sync.Mutex
+	rnd      *rand.Rand
+}
+
+// NewLoadingMap creates a LoadingMap that calls loader to populate missing or expired keys. The
+// given options are applied to every Value in the map.
+func NewLoadingMap[K comparable, V comparable](loader func(ctx context.Context, key K) (V, error), cfg LoadingConfig[K], opts ...Option) LoadingMap[K, V] {
+	if cfg.TTL <= 0 {
+		cfg.TTL = tenYears
+	}
+	m := &loadingMap[K, V]{
+		emap:   NewMap[K, V](opts...).(*emap[K, V]),
+		loader: loader,
+		cfg:    cfg,
+		timers: make(map[K]Timer),
+		rnd:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	if cfg.MaxConcurrentRefreshes > 0 {
+		m.slots = make(chan struct{}, cfg.MaxConcurrentRefreshes)
+	}
+	return m
+}
+
+func (m *loadingMap[K, V]) Load(ctx context.Context, key K) (V, error) {
+	p := m.policy(key)
+	loaded := false
+	load := func() (V, error) {
+		loaded = true
+		return m.load(ctx, key, p)
+	}
+	v := m.getValue(key)
+	now := m.clock.Now()
+	var result V
+	var err error
+	if p.NegativeTTL > 0 {
+		result, err = v.getOrLoad(now.Add(p.TTL), now.Add(p.NegativeTTL), load)
+	} else {
+		result, err = v.GetOrSetExpiring(now.Add(p.TTL), load)
+	}
+	if err == nil && loaded {
+		m.scheduleRefresh(key, p)
+	}
+	return result, err
+}
+
+// policy returns the configuration for key.
+func (m *loadingMap[K, V]) policy(key K) KeyPolicy {
+	p := KeyPolicy{
+		LoaderTimeout: m.cfg.LoaderTimeout,
+		TTL:           m.cfg.TTL,
+		NegativeTTL:   m.cfg.NegativeTTL,
+		RefreshAhead:  m.cfg.RefreshAhead,
+	}
+	if m.cfg.Policy == nil {
+		return p
+	}
+	override := m.cfg.Policy(key)
+	if override.LoaderTimeout != 0 {
+		p.LoaderTimeout = override.LoaderTimeout
+	}
+	if override.TTL != 0 {
+		p.TTL = override.TTL
+	}
+	if override.NegativeTTL != 0 {
+		p.NegativeTTL = override.NegativeTTL
+	}
+	if override.RefreshAhead != 0 {
+		p.RefreshAhead = override.RefreshAhead
+	}
+	return p
+}
+
+// load calls the loader for key, bounded by the key's loader timeout.
+func (m *loadingMap[K, V]) load(ctx context.Context, key K, p KeyPolicy) (V, error) {
+	if p.LoaderTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.LoaderTimeout)
+		defer cancel()
+	}
+	return m.loader(ctx, key)
+}
+
+type scope[K comparable, V comparable] struct {
+	m    *loadingMap[K, V]
+	ctx  context.Context
+	mx   sync.Mutex
+	memo map[K]*value[V]
+}
+
+func (m *loadingMap[K, V]) Scoped(ctx context.Context) Scope[K, V] {
+	return &scope[K, V]{m: m, ctx: ctx, memo: make(map[K]*value[V])}
+}
+
+func (s *scope[K, V]) Load(key K) (V, error) {
+	s.mx.Lock()
+	v := s.memo[key]
+	if v == nil {
+		v = newValueExact[V](nil)
+		s.memo[key] = v
+	}
+	s.mx.Unlock()
+	return v.GetOrSetExpiring(v.clock.Now().Add(tenYears), func() (V, error) {
+		return s.m.Load(s.ctx, key)
+	})
+}
+
+func (m *loadingMap[K, V]) Reset(key K) {
+	m.cancelRefresh(key)
+	m.emap.Reset(key)
+}
+
+func (m *loadingMap[K, V]) Stop() {
+	m.timersMx.Lock()
+	m.stopped = true
+	for key, timer := range m.timers {
+		timer.Stop()
+		delete(m.timers, key)
+	}
+	m.timersMx.Unlock()
+	m.cancel()
+}
+
+// refreshDelay returns how long to wait before refreshing a value that was just loaded.
+func (m *loadingMap[K, V]) refreshDelay(p KeyPolicy) time.Duration {
+	ahead := p.RefreshAhead
+	if ahead >= p.TTL {
+		// Refreshing this early would reload the key continuously
+		ahead = p.TTL / 2
+	}
+	delay := p.TTL - ahead
+	if m.cfg.RefreshJitter > 0 {
+		m.randMx.Lock()
+		delay -= time.Duration(m.rnd.Int63n(int64(m.cfg.RefreshJitter)))
+		m.randMx.Unlock()
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return delay
+}
+
+func (m *loadingMap[K, V]) scheduleRefresh(key K, p KeyPolicy) {
+	if p.RefreshAhead <= 0 {
+		return
+	}
+
+	m.timersMx.Lock()
+	defer m.timersMx.Unlock()
+	if m.stopped {
+		return
+	}
+	if existing := m.timers[key]; existing != nil {
+		existing.Stop()
+	}
+	m.timers[key] = m.clock.AfterFunc(m.refreshDelay(p), func() {
+		m.refresh(key)
+	})
+}
+
+func (m *loadingMap[K, V]) cancelRefresh(key K) {
+	m.timersMx.Lock()
+	defer m.timersMx.Unlock()
+	if timer := m.timers[key]; timer != nil {
+		timer.Stop()
+		delete(m.timers, key)
+	}
+}
+
+func (m *loadingMap[K, V]) refresh(key K) {
+	if m.slots != nil {
+		select {
+		case m.slots <- struct{}{}:
+			defer func() { <-m.slots }()
+		case <-m.ctx.Done():
+			return
+		}
+	}
+
+	p := m.policy(key)
+	v := m.getValue(key)
+	v.m.Lock()
+	version := v.Version()
+	v.beginLoad()
+	v.m.Unlock()
+	result, err := m.load(m.ctx, key, p)
+	v.m.Lock()
+	v.endLoad()
+	v.m.Unlock()
+	if m.ctx.Err() != nil {
+		// Stopped while the loader was running, don't store or report anything
+		return
+	}
+	v.m.Lock()
+	v.recordError(err)
+	v.m.Unlock()
+	if err != nil {
+		m.cancelRefresh(key)
+		if m.cfg.OnRefreshFailure != nil {
+			m.cfg.OnRefreshFailure(key, err)
+		}
+		return
+	}
+	v.m.Lock()
+	if v.Changed(version) {
+		// Set or Reset while the loader was running, so result is already out of date. Keep
+		// refreshing a key that was Set, but not one that was Reset, which must stay unset.
+		set := v.set
+		v.m.Unlock()
+		if set {
+			m.scheduleRefresh(key, p)
+		}
+		return
+	}
+	writeErr := v.writable()
+	if writeErr == nil {
+		v.doSetExpiring(result, m.clock.Now().Add(p.TTL))
+	}
+	v.m.Unlock()
+	if writeErr != nil {
+		v.reportMisuse(writeErr)
+	}
+	m.scheduleRefresh(key, p)
+	if m.cfg.OnRefreshSuccess != nil {
+		m.cfg.OnRefreshSuccess(key)
+	}
+}
@@ -0,0 +1,296 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadingMap(t *testing.T) {
+	var loads int32
+	m := NewLoadingMap[string, string](func(ctx context.Context, key string) (string, error) {
+		n := atomic.AddInt32(&loads, 1)
+		return fmt.Sprintf("%s%d", key, n), nil
+	}, LoadingConfig[string]{TTL: 50 * time.Millisecond})
+	defer m.Stop()
+
+	r, err := m.Load(context.Background(), "a")
+	require.NoError(t, err)
+	require.Equal(t, "a1", r)
+
+	r, err = m.Load(context.Background(), "a")
+	require.NoError(t, err)
+	require.Equal(t, "a1", r, "second load should be served from the map")
+
+	r, err = m.Get(DontWait, "a")
+	require.NoError(t, err)
+	require.Equal(t, "a1", r)
+
+	time.Sleep(60 * time.Millisecond)
+	r, err = m.Load(context.Background(), "a")
+	require.NoError(t, err)
+	require.Equal(t, "a2", r, "expired value should be reloaded")
+}
+
+func TestLoadingMapScoped(t *testing.T) {
+	var loads int32
+	clock := newFakeClock()
+	m := NewLoadingMap[string, string](func(ctx context.Context, key string) (string, error) {
+		n := atomic.AddInt32(&loads, 1)
+		return fmt.Sprintf("%s%d", key, n), nil
+	}, LoadingConfig[string]{TTL: time.Millisecond}, WithClock(clock))
+	defer m.Stop()
+
+	scope := m.Scoped(context.Background())
+	for i := 0; i < 5; i++ {
+		r, err := scope.Load("a")
+		require.NoError(t, err)
+		require.Equal(t, "a1", r, "scope should remember what it loaded")
+		clock.Advance(time.Millisecond)
+	}
+	_, err := m.Get(DontWait, "a")
+	require.Error(t, err, "scope shouldn't keep the value alive in the map")
+
+	r, err := m.Scoped(context.Background()).Load("a")
+	require.NoError(t, err)
+	require.Equal(t, "a2", r, "another scope should load again")
+}
+
+func TestLoadingMapRefreshAhead(t *testing.T) {
+	const ttl = 100 * time.Millisecond
+
+	var (
+		loads     int32
+		fail      int32
+		mx        sync.Mutex
+		refreshed []string
+		failed    []string
+	)
+	m := NewLoadingMap[string, int32](func(ctx context.Context, key string) (int32, error) {
+		if atomic.LoadInt32(&fail) == 1 {
+			return 0, errors.New("backend down")
+		}
+		return atomic.AddInt32(&loads, 1), nil
+	}, LoadingConfig[string]{
+		TTL:           ttl,
+		RefreshAhead:  ttl / 2,
+		RefreshJitter: ttl / 10,
+		OnRefreshSuccess: func(key string) {
+			mx.Lock()
+			refreshed = append(refreshed, key)
+			mx.Unlock()
+		},
+		OnRefreshFailure: func(key string, err error) {
+			mx.Lock()
+			failed = append(failed, key)
+			mx.Unlock()
+		},
+	})
+	defer m.Stop()
+
+	_, err := m.Load(context.Background(), "a")
+	require.NoError(t, err)
+
+	time.Sleep(ttl * 3 / 4)
+	mx.Lock()
+	require.Equal(t, []string{"a"}, refreshed, "key should have been refreshed before expiring")
+	mx.Unlock()
+	r, err := m.Get(DontWait, "a")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, r)
+
+	atomic.StoreInt32(&fail, 1)
+	time.Sleep(ttl * 3 / 4)
+	mx.Lock()
+	require.Equal(t, []string{"a"}, failed, "failed refresh should be reported")
+	mx.Unlock()
+}
+
+func TestLoadingMapResetDuringRefresh(t *testing.T) {
+	clock := newFakeClock()
+	var loads int32
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var refreshed int32
+	m := NewLoadingMap[string, int32](func(ctx context.Context, key string) (int32, error) {
+		n := atomic.AddInt32(&loads, 1)
+		if n > 1 {
+			started <- struct{}{}
+			<-release
+		}
+		return n, nil
+	}, LoadingConfig[string]{
+		TTL:              time.Minute,
+		RefreshAhead:     10 * time.Second,
+		OnRefreshSuccess: func(key string) { atomic.AddInt32(&refreshed, 1) },
+	}, WithClock(clock))
+	defer m.Stop()
+
+	_, err := m.Load(context.Background(), "a")
+	require.NoError(t, err)
+
+	advanced := make(chan struct{})
+	go func() {
+		defer close(advanced)
+		clock.Advance(55 * time.Second)
+	}()
+	<-started
+	m.Reset("a")
+	close(release)
+	<-advanced
+
+	_, err = m.Get(DontWait, "a")
+	require.Error(t, err, "refresh shouldn't bring back a key that was reset")
+	require.EqualValues(t, 0, atomic.LoadInt32(&refreshed), "dropped refresh shouldn't be reported")
+	require.Equal(t, 0, clock.pending(), "reset key shouldn't be refreshed again")
+}
+
+func TestLoadingMapRefreshAheadBeyondTTL(t *testing.T) {
+	clock := newFakeClock()
+	var loads int32
+	m := NewLoadingMap[string, int32](func(ctx context.Context, key string) (int32, error) {
+		return atomic.AddInt32(&loads, 1), nil
+	}, LoadingConfig[string]{
+		TTL:          time.Minute,
+		RefreshAhead: 30 * time.Second,
+		Policy: func(key string) KeyPolicy {
+			return KeyPolicy{TTL: 10 * time.Second}
+		},
+	}, WithClock(clock))
+	defer m.Stop()
+
+	_, err := m.Load(context.Background(), "a")
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	require.EqualValues(t, 1, atomic.LoadInt32(&loads), "key shouldn't be refreshed continuously")
+
+	clock.Advance(5 * time.Second)
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&loads) == 2
+	}, time.Second, time.Millisecond, "key should be refreshed halfway through its TTL")
+	time.Sleep(10 * time.Millisecond)
+	require.EqualValues(t, 2, atomic.LoadInt32(&loads))
+}
+
+func TestLoadingMapRefreshConcurrencyCap(t *testing.T) {
+	const keys = 10
+
+	var running, maxRunning, refreshes int32
+	m := NewLoadingMap[int, int](func(ctx context.Context, key int) (int, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return key, nil
+	}, LoadingConfig[int]{
+		TTL:                    10 * time.Millisecond,
+		RefreshAhead:           9 * time.Millisecond,
+		MaxConcurrentRefreshes: 2,
+		OnRefreshSuccess: func(key int) {
+			atomic.AddInt32(&refreshes, 1)
+		},
+	})
+
+	for i := 0; i < keys; i++ {
+		_, err := m.Load(context.Background(), i)
+		require.NoError(t, err)
+	}
+	atomic.StoreInt32(&maxRunning, 0)
+	time.Sleep(50 * time.Millisecond)
+	m.Stop()
+	require.Greater(t, atomic.LoadInt32(&refreshes), int32(keys))
+	require.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(2))
+}
+
+func TestLoadingMapLastError(t *testing.T) {
+	failure := errors.New("not found")
+	m := NewLoadingMap[string, string](func(ctx context.Context, key string) (string, error) {
+		return "", failure
+	}, LoadingConfig[string]{})
+	defer m.Stop()
+
+	_, err := m.Load(context.Background(), "a")
+	require.Equal(t, failure, err)
+	err, at := m.LastError("a")
+	require.Equal(t, failure, err)
+	require.False(t, at.IsZero())
+
+	err, at = m.LastError("b")
+	require.NoError(t, err)
+	require.True(t, at.IsZero())
+	require.Equal(t, 1, m.Stats().Keys, "LastError shouldn't start tracking keys")
+}
+
+func TestLoadingMapKeyPolicy(t *testing.T) {
+	clock := newFakeClock()
+	var loads int32
+	notFound := errors.New("not found")
+	m := NewLoadingMap[string, string](func(ctx context.Context, key string) (string, error) {
+		atomic.AddInt32(&loads, 1)
+		switch key {
+		case "slow":
+			<-ctx.Done()
+			return "", ctx.Err()
+		case "missing":
+			return "", notFound
+		}
+		return key, nil
+	}, LoadingConfig[string]{
+		TTL:          time.Hour,
+		RefreshAhead: time.Minute,
+		Policy: func(key string) KeyPolicy {
+			switch key {
+			case "short":
+				return KeyPolicy{TTL: time.Minute, RefreshAhead: -1}
+			case "slow":
+				return KeyPolicy{LoaderTimeout: 10 * time.Millisecond}
+			case "missing":
+				return KeyPolicy{NegativeTTL: time.Second}
+			}
+			return KeyPolicy{}
+		},
+	}, WithClock(clock))
+	defer m.Stop()
+	refreshes := func() int {
+		lm := m.(*loadingMap[string, string])
+		lm.timersMx.Lock()
+		defer lm.timersMx.Unlock()
+		return len(lm.timers)
+	}
+
+	_, err := m.Load(context.Background(), "short")
+	require.NoError(t, err)
+	require.Equal(t, 0, refreshes(), "refresh-ahead should be disabled for the key")
+	clock.Advance(time.Minute)
+	_, err = m.Get(DontWait, "short")
+	require.Error(t, err, "key should expire after its own TTL")
+
+	_, err = m.Load(context.Background(), "default")
+	require.NoError(t, err)
+	require.Equal(t, 1, refreshes(), "other keys should keep the configured refresh-ahead")
+
+	_, err = m.Load(context.Background(), "slow")
+	require.Equal(t, context.DeadlineExceeded, err, "loader should be bounded by the key's timeout")
+
+	atomic.StoreInt32(&loads, 0)
+	_, err = m.Load(context.Background(), "missing")
+	require.Equal(t, notFound, err)
+	_, err = m.Load(context.Background(), "missing")
+	require.Equal(t, notFound, err)
+	require.EqualValues(t, 1, atomic.LoadInt32(&loads), "error should be cached for the key's negative TTL")
+	clock.Advance(time.Second)
+	_, err = m.Load(context.Background(), "missing")
+	require.Equal(t, notFound, err)
+	require.EqualValues(t, 2, atomic.LoadInt32(&loads), "loader should be retried once the error expires")
+}
@@ -2,10 +2,20 @@ package eventual
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math/rand"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// ErrNoSuchKey is returned by Map.GetOrWait when the Map isn't tracking the requested key.
+var ErrNoSuchKey = errors.New("eventual: no such key")
+
 // Map is a map of eventual values, meaning that callers wishing to access the value block until it is
 // available.
 type Map[K comparable, V comparable] interface {
@@ -15,6 +25,40 @@ type Map[K comparable, V comparable] interface {
 	// Set the Value at key, expiring at the given time.
 	SetExpiring(key K, value V, expiration time.Time)
 
+	// SetWithProvenance sets the Value at key, recording where it came from. See
+	// Value.SetWithProvenance.
+	SetWithProvenance(key K, value V, provenance Provenance)
+
+	// Provenance returns the provenance of the value at key. See Value.Provenance.
+	Provenance(key K) Provenance
+
+	// SetError makes the Value at key fail with err. See Value.SetError.
+	SetError(key K, err error)
+
+	// Fail releases every caller currently blocked waiting for key with err. See Value.Fail.
+	Fail(key K, err error)
+
+	// SetIfAbsent sets the Value at key only if it isn't currently set. See Value.SetIfAbsent.
+	SetIfAbsent(key K, value V) bool
+
+	// SetMany sets the Values at all of the given keys. Once SetMany returns, every subsequent Get
+	// from any goroutine observes the complete batch, whichever shards the keys are in. Gets that
+	// run concurrently with SetMany may observe part of the batch.
+	SetMany(entries map[K]V)
+
+	// SetExpiringIn sets the Value at key, expiring ttl from now. See Value.SetExpiringIn.
+	SetExpiringIn(key K, value V, ttl time.Duration)
+
+	// Touch moves the expiration of the value at key. See Value.Touch.
+	Touch(key K, newExpiration time.Time) bool
+
+	// ExpireAll moves the expiration of every value in the Map to at, or if window is positive, to
+	// a random time between at and at+window, and returns how many values it moved. Staggering a
+	// coordinated flush, for example after correcting upstream data, avoids the stampede of
+	// reloads that clearing every key at once would cause. Values that have already expired are
+	// left alone.
+	ExpireAll(at time.Time, window time.Duration) int
+
 	// Reset clears the currently set value at key, reverting to the same state as if the Eventual had just
 	// been created.
 	Reset(key K)
@@ -26,21 +70,244 @@ type Map[K comparable, V comparable] interface {
 	// returned. For convenience, see DontWait.
 	Get(ctx context.Context, key K) (V, error)
 
+	// GetOrWait is like Get, but doesn't start tracking key if the Map isn't tracking it already.
+	// Instead, it returns ErrNoSuchKey immediately. This allows probing speculative keys without
+	// polluting the Map.
+	GetOrWait(ctx context.Context, key K) (V, error)
+
+	// Peek returns the value at key and true if it's set and hasn't expired, without ever blocking.
+	// Like GetOrWait, it doesn't start tracking unknown keys. Defaults and fallbacks are ignored,
+	// see Value.Peek.
+	Peek(key K) (V, bool)
+
+	// TryGet is like Get with an expired context, such as DontWait, but if no value, default or
+	// fallback is available, it returns why, such as ErrNotSet, ErrExpired or the error the key
+	// failed with, instead of the context's error. Like Peek, it doesn't start tracking unknown
+	// keys.
+	TryGet(key K) (V, error)
+
+	// SetExpiringSoft sets the Value at key with distinct soft and hard expirations. See
+	// Value.SetExpiringSoft.
+	SetExpiringSoft(key K, value V, softExpiration time.Time, hardExpiration time.Time)
+
+	// GetOrSetSoftExpiring is like GetOrSetExpiring, but serves stale values while refreshing them
+	// in the background. See Value.GetOrSetSoftExpiring.
+	GetOrSetSoftExpiring(key K, softExpiration time.Time, hardExpiration time.Time, getter func() (V, error)) (V, error)
+
+	// GetOrSetWithFreshness is like GetOrSetSoftExpiring, but also reports the freshness of the
+	// result. See Value.GetOrSetWithFreshness.
+	GetOrSetWithFreshness(key K, softExpiration time.Time, hardExpiration time.Time, getter func() (V, error)) (V, Freshness, error)
+
+	// GetWithBudget is like Get, but stops waiting reserve before the context's deadline, so that
+	// the caller has time left to execute its fallback path. See Value.GetWithBudget.
+	GetWithBudget(ctx context.Context, key K, reserve time.Duration) (V, error)
+
 	// Gets the stored value at key, or if none available, runs the given func, stores the value as an expiring value,
 	// and returns the result. If func() returns an error, nothing is stored and the error is returned to caller.
 	GetOrSetExpiring(key K, expiration time.Time, getter func() (V, error)) (V, error)
+
+	// GetAllWithin waits for each of the given keys for up to its own budget, measured from the
+	// start of the call, and returns the values that became available along with errors for the
+	// keys that didn't. ctx bounds the entire call. Keys are waited on without spawning goroutines.
+	GetAllWithin(ctx context.Context, deadlines map[K]time.Duration) (map[K]V, map[K]error)
+
+	// TryGetMany returns the values of the given keys that are currently set, without waiting, along
+	// with the keys that aren't, in the order they were given. Like GetOrWait, it doesn't start
+	// tracking unknown keys. Defaults and fallbacks are ignored, see Value.Peek.
+	TryGetMany(keys ...K) (found map[K]V, missing []K)
+
+	// WaitAllSet waits until every one of the given keys has been set. If the context expires
+	// first, its error is returned. Defaults don't count as being set.
+	WaitAllSet(ctx context.Context, keys ...K) error
+
+	// WaitCount waits until at least n keys are set at the same time. If the context expires first,
+	// its error is returned.
+	WaitCount(ctx context.Context, n int) error
+
+	// LastError returns the error from the most recent attempt to populate key with a getter or
+	// loader, along with when it happened. See Value.LastError. For keys that the Map isn't
+	// tracking, it returns nil and the zero time, without starting to track them.
+	LastError(key K) (error, time.Time)
+
+	// Invalidate resets key if it currently holds a value, reporting whether it did. Unlike Reset,
+	// it doesn't start tracking keys that aren't in the Map yet. Invalidate makes every Map an
+	// Invalidator.
+	Invalidate(key K) bool
+
+	// Export writes every key that currently holds an unexpired value to w in a streaming,
+	// length-prefixed format, encoding keys and values with the configured codecs (see
+	// WithKeyCodec and WithCodec).
+	Export(w io.Writer) error
+
+	// Import reads entries written by Export from r and sets them in the Map, keeping their
+	// original expirations. Entries that have expired in the meantime are skipped.
+	Import(r io.Reader) error
+
+	// ForEachPending calls fn for every key that has callers waiting for a value, with the number
+	// of waiters and how long the oldest of them has been waiting. This is useful for debugging
+	// pipelines that are stuck waiting on values that never arrive.
+	ForEachPending(fn func(key K, waiters int, oldest time.Duration))
+
+	// Check reports the Map as unhealthy while more keys have callers waiting for a value than
+	// configured with WithMaxPendingKeys. Check makes every Map a Checker.
+	Check(ctx context.Context) error
+
+	// LockableMap allows the Map to take part in ReadConsistent.
+	LockableMap
+
+	// Stats returns a point-in-time summary of the Map.
+	Stats() Stats
+
+	// Dump writes a line for every key the Map is tracking to w, sorted by key, describing the
+	// state of its Value like Value's String method does, including whether it's set, when it
+	// expires and how many callers are waiting for it. This helps debug callers stuck in Get.
+	// Payloads are summarized rather than written out, see WithVisiblePayloads.
+	Dump(w io.Writer) error
+
+	// ShardOccupancy returns the number of keys in each of the Map's shards (see WithShards), which
+	// helps detect keys that hash poorly.
+	ShardOccupancy() []int
+
+	// StatsByClass is like Stats, but broken down by the class of each key, as assigned by the
+	// configured classifier (see WithKeyClassifier). Without a classifier, every key is in the
+	// class "".
+	StatsByClass() map[string]Stats
+
+	// ExpiryHistogram returns the distribution of the remaining TTLs of the keys that currently hold
+	// an unexpired value, for forecasting refresh load. buckets are upper bounds in ascending
+	// order. The result has one more element than buckets: element i counts the keys that expire
+	// within buckets[i] but not within buckets[i-1], and the last element counts the keys that
+	// expire later than the last bucket.
+	ExpiryHistogram(buckets []time.Duration) []int
+
+	// Events returns an iterator over the updates to the Map's keys, starting with the first update
+	// after the iteration begins, until ctx is done or the loop is exited. Updates to a key that
+	// occur while the consumer is busy are coalesced, so a slow consumer only sees the latest
+	// update for each key.
+	Events(ctx context.Context) iter.Seq2[K, Update[V]]
+
+	// Batch returns a Batch that accumulates changes to the Map until they're committed together.
+	Batch() Batch[K, V]
+
+	// Clone returns an independent Map with the same configuration, defaults and entries (see
+	// Value.Clone). The clone of a specialized Map, such as a LoadingMap, is a plain Map.
+	Clone() Map[K, V]
+
+	// Close closes every Value in the Map and stops any background work. Callers blocked waiting
+	// for a key are released with ErrClosed, as are subsequent Gets. Subsequent writes are dropped
+	// and reported to the misuse handler (see WithMisuseHandler), and iterations over Events end.
+	// Close is idempotent.
+	Close()
+}
+
+// Stats summarizes the contents of a Map.
+type Stats struct {
+	// Keys is the number of keys the Map is tracking, whether or not they currently hold a value.
+	Keys int
+
+	// Bytes is the approximate size of all stored payloads, as reported by the configured weigher
+	// (see WithWeigher). It's always 0 when no weigher is configured.
+	Bytes int
 }
 
 type emap[K comparable, V comparable] struct {
-	m  map[K]Value[V]
-	mx sync.Mutex
+	shards   []*shard[K, V]
+	shardFn  func(K) uint32
+	classify func(K) string
+	mask     uint32
+	opts     []Option
+	clock    Clock
+	interval time.Duration // minimum time between updates yielded by Events
+	defaults map[K]V
+	codec    Codec[V]
+	keyCodec Codec[K]
+
+	closeOnce sync.Once
+	closed    atomic.Bool
+	done      chan struct{}
+	// onCreate, if set, is called with every key the Map starts tracking, while holding its
+	// shard's lock.
+	onCreate func(K)
+	// closedValue stands in for keys that the Map wasn't tracking when it was closed.
+	closedValue *value[V]
+
+	subscribersMx  sync.RWMutex
+	subscribers    map[*mapSubscriber[K, V]]struct{}
+	numSubscribers int32
+	seq            uint64 // accessed atomically
+
+	// skewThreshold and onSkew are configured by WithSkewHandler. creations counts the keys the
+	// Map started tracking, and skewed is whether the last check found the shards skewed.
+	skewThreshold float64
+	onSkew        func(occupancy []int)
+	creations     atomic.Uint64
+	skewed        atomic.Bool
+
+	// maxPending is configured by WithMaxPendingKeys.
+	maxPending int
+	// sensitive is configured by WithSensitive.
+	sensitive bool
+	// id orders Maps for ReadConsistent.
+	id uint64
 }
 
-func NewMap[K comparable, V comparable]() Map[K, V] {
-	return &emap[K, V]{
-		m: make(map[K]Value[V]),
+// NewMap creates a new Map. The given options are applied to every Value in the Map. Keys are
+// spread across a number of independently locked shards, see WithShards and WithShardFn.
+func NewMap[K comparable, V comparable](opts ...Option) Map[K, V] {
+	return newMap[K, V](withDefaults(opts))
+}
+
+// newMap is like NewMap, but doesn't apply the defaults set with SetDefaults, because opts already
+// include them. Every Value in the Map is created with the same options, even if the defaults
+// change later.
+func newMap[K comparable, V comparable](opts []Option) *emap[K, V] {
+	o := buildOptions(opts)
+	m := &emap[K, V]{
+		shards:   make([]*shard[K, V], shardCount(o.shards)),
+		shardFn:  typedOption[func(K) uint32]("WithShardFn", o.shardFn),
+		classify: typedOption[func(K) string]("WithKeyClassifier", o.classifier),
+		opts:     opts,
+		clock:    o.clock,
+		interval: o.notifyInterval,
+		done:     make(chan struct{}),
+
+		skewThreshold: o.skewThreshold,
+		onSkew:        o.onSkew,
+
+		maxPending: o.maxPending,
+		sensitive:  o.sensitive,
+		id:         mapIDs.Add(1),
+	}
+	m.codec = typedOption[Codec[V]]("WithCodec", o.codec)
+	if m.codec == nil {
+		m.codec = JSONCodec[V]{}
+	}
+	m.keyCodec = typedOption[Codec[K]]("WithKeyCodec", o.keyCodec)
+	if m.keyCodec == nil {
+		m.keyCodec = JSONCodec[K]{}
+	}
+	for i := range m.shards {
+		m.shards[i] = &shard[K, V]{m: make(map[K]*value[V])}
+	}
+	m.mask = uint32(len(m.shards) - 1)
+	if m.shardFn == nil {
+		m.shardFn = hashFn[K]()
+	}
+	return m
+}
+
+// NewMapWithDefaults creates a new Map in which Get on any of the keys in defaults returns that
+// key's default if a real value isn't available in time (see WithDefault). Other keys behave as in
+// a Map created with NewMap.
+func NewMapWithDefaults[K comparable, V comparable](defaults map[K]V, opts ...Option) Map[K, V] {
+	m := NewMap[K, V](opts...).(*emap[K, V])
+	m.defaults = make(map[K]V, len(defaults))
+	for key, defaultValue := range defaults {
+		m.defaults[key] = defaultValue
 	}
+	return m
 }
+
 func (m *emap[K, V]) Set(key K, value V) {
 	v := m.getValue(key)
 	v.Set(value)
@@ -51,6 +318,77 @@ func (m *emap[K, V]) SetExpiring(key K, value V, expiration time.Time) {
 	v.SetExpiring(value, expiration)
 }
 
+func (m *emap[K, V]) SetMany(entries map[K]V) {
+	// Every Set publishes its value while holding the value's mutex, which happens before the next
+	// Get of that value observes it, so the whole batch is visible once the last Set returns.
+	for key, value := range entries {
+		m.Set(key, value)
+	}
+}
+
+func (m *emap[K, V]) SetExpiringIn(key K, value V, ttl time.Duration) {
+	v := m.getValue(key)
+	v.SetExpiringIn(value, ttl)
+}
+
+func (m *emap[K, V]) SetWithProvenance(key K, value V, provenance Provenance) {
+	v := m.getValue(key)
+	v.SetWithProvenance(value, provenance)
+}
+
+func (m *emap[K, V]) Provenance(key K) Provenance {
+	if v := m.existingValue(key); v != nil {
+		return v.Provenance()
+	}
+	return Provenance{}
+}
+
+func (m *emap[K, V]) SetError(key K, err error) {
+	v := m.getValue(key)
+	v.SetError(err)
+}
+
+func (m *emap[K, V]) Fail(key K, err error) {
+	if v := m.existingValue(key); v != nil {
+		v.Fail(err)
+	}
+}
+
+func (m *emap[K, V]) SetIfAbsent(key K, value V) bool {
+	v := m.getValue(key)
+	return v.SetIfAbsent(value)
+}
+
+func (m *emap[K, V]) Touch(key K, newExpiration time.Time) bool {
+	if v := m.existingValue(key); v != nil {
+		return v.Touch(newExpiration)
+	}
+	return false
+}
+
+func (m *emap[K, V]) ExpireAll(at time.Time, window time.Duration) int {
+	var values []*value[V]
+	for _, s := range m.shards {
+		s.mx.Lock()
+		for _, v := range s.m {
+			values = append(values, v)
+		}
+		s.mx.Unlock()
+	}
+
+	moved := 0
+	for _, v := range values {
+		t := at
+		if window > 0 {
+			t = t.Add(time.Duration(rand.Int63n(int64(window))))
+		}
+		if v.Touch(t) {
+			moved++
+		}
+	}
+	return moved
+}
+
 func (m *emap[K, V]) Reset(key K) {
 	v := m.getValue(key)
 	v.Reset()
@@ -61,20 +399,365 @@ func (m *emap[K, V]) Get(ctx context.Context, key K) (V, error) {
 	return v.Get(ctx)
 }
 
+func (m *emap[K, V]) GetOrWait(ctx context.Context, key K) (V, error) {
+	v := m.existingValue(key)
+	if v == nil {
+		var zero V
+		return zero, ErrNoSuchKey
+	}
+	return v.Get(ctx)
+}
+
+func (m *emap[K, V]) Peek(key K) (V, bool) {
+	v := m.existingValue(key)
+	if v == nil {
+		var zero V
+		return zero, false
+	}
+	return v.Peek()
+}
+
+func (m *emap[K, V]) TryGet(key K) (V, error) {
+	v := m.existingValue(key)
+	if v == nil {
+		if m.closed.Load() {
+			v = m.closedValue
+		} else {
+			// Ask a Value that isn't added to the Map, which falls back like a new key would
+			v = m.newValue(key)
+		}
+	}
+	return v.tryGet()
+}
+
+func (m *emap[K, V]) SetExpiringSoft(key K, value V, softExpiration time.Time, hardExpiration time.Time) {
+	v := m.getValue(key)
+	v.SetExpiringSoft(value, softExpiration, hardExpiration)
+}
+
+func (m *emap[K, V]) GetOrSetSoftExpiring(key K, softExpiration time.Time, hardExpiration time.Time, getter func() (V, error)) (V, error) {
+	v := m.getValue(key)
+	return v.GetOrSetSoftExpiring(softExpiration, hardExpiration, getter)
+}
+
+func (m *emap[K, V]) GetOrSetWithFreshness(key K, softExpiration time.Time, hardExpiration time.Time, getter func() (V, error)) (V, Freshness, error) {
+	v := m.getValue(key)
+	return v.GetOrSetWithFreshness(softExpiration, hardExpiration, getter)
+}
+
+func (m *emap[K, V]) GetWithBudget(ctx context.Context, key K, reserve time.Duration) (V, error) {
+	v := m.getValue(key)
+	return v.GetWithBudget(ctx, reserve)
+}
+
 func (m *emap[K, V]) GetOrSetExpiring(key K, expiration time.Time, getter func() (V, error)) (V, error) {
 	v := m.getValue(key)
 	return v.GetOrSetExpiring(expiration, getter)
 }
 
-func (m *emap[K, V]) getValue(key K) Value[V] {
-	m.mx.Lock()
-	defer m.mx.Unlock()
+func (m *emap[K, V]) LastError(key K) (error, time.Time) {
+	v := m.existingValue(key)
+	if v == nil {
+		return nil, time.Time{}
+	}
+	return v.LastError()
+}
+
+func (m *emap[K, V]) GetAllWithin(ctx context.Context, deadlines map[K]time.Duration) (map[K]V, map[K]error) {
+	start := m.clock.Now()
+	keys := make([]K, 0, len(deadlines))
+	for key := range deadlines {
+		keys = append(keys, key)
+	}
+	// Waiting on keys in order of their deadlines means every key gets its full budget even though
+	// we only wait on one at a time.
+	sort.Slice(keys, func(i, j int) bool {
+		return deadlines[keys[i]] < deadlines[keys[j]]
+	})
+
+	found := make(map[K]V, len(keys))
+	var errs map[K]error
+	for _, key := range keys {
+		keyCtx, cancel := withDeadline(ctx, m.clock, start.Add(deadlines[key]))
+		result, err := m.Get(keyCtx, key)
+		cancel()
+		if err != nil {
+			if errs == nil {
+				errs = make(map[K]error)
+			}
+			errs[key] = err
+			continue
+		}
+		found[key] = result
+	}
+	return found, errs
+}
+
+func (m *emap[K, V]) TryGetMany(keys ...K) (map[K]V, []K) {
+	found := make(map[K]V, len(keys))
+	var missing []K
+	for _, key := range keys {
+		if v := m.existingValue(key); v != nil {
+			if result, ok := v.Peek(); ok {
+				found[key] = result
+				continue
+			}
+		}
+		missing = append(missing, key)
+	}
+	return found, missing
+}
+
+func (m *emap[K, V]) WaitAllSet(ctx context.Context, keys ...K) error {
+	for _, key := range keys {
+		if err := m.getValue(key).Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *emap[K, V]) WaitCount(ctx context.Context, n int) error {
+	// Subscribe before counting so that we can't miss a Set that happens in between
+	sub := m.subscribe()
+	defer m.unsubscribe(sub)
+	for {
+		if m.countSet() >= n {
+			return nil
+		}
+		select {
+		case <-sub.signal:
+			sub.drain()
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-m.done:
+			return ErrClosed
+		}
+	}
+}
+
+// countSet counts the keys that currently hold an unexpired value.
+func (m *emap[K, V]) countSet() int {
+	count := 0
+	now := m.clock.Now()
+	for _, s := range m.shards {
+		s.mx.Lock()
+		for _, v := range s.m {
+			if v.snapshot().valid(now) {
+				count++
+			}
+		}
+		s.mx.Unlock()
+	}
+	return count
+}
+
+func (m *emap[K, V]) ForEachPending(fn func(key K, waiters int, oldest time.Duration)) {
+	type pendingKey struct {
+		key     K
+		waiters int
+		since   time.Time
+	}
+	// Collect everything first so that fn is free to use the Map
+	var pending []pendingKey
+	for _, s := range m.shards {
+		s.mx.Lock()
+		for key, v := range s.m {
+			if waiters, since := v.pending(); waiters > 0 {
+				pending = append(pending, pendingKey{key, waiters, since})
+			}
+		}
+		s.mx.Unlock()
+	}
+
+	now := m.clock.Now()
+	for _, p := range pending {
+		fn(p.key, p.waiters, now.Sub(p.since))
+	}
+}
+
+func (m *emap[K, V]) Invalidate(key K) bool {
+	v := m.existingValue(key)
+	if v == nil || !v.IsSet() {
+		return false
+	}
+	v.Reset()
+	return true
+}
+
+func (m *emap[K, V]) shardFor(key K) *shard[K, V] {
+	if len(m.shards) == 1 {
+		return m.shards[0]
+	}
+	return m.shards[m.shardFn(key)&m.mask]
+}
+
+// existingValue returns the value at key, or nil if the Map isn't tracking key.
+func (m *emap[K, V]) existingValue(key K) *value[V] {
+	s := m.shardFor(key)
+	if result := s.lookup(key); result != nil {
+		return result
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.m[key]
+}
+
+func (m *emap[K, V]) getValue(key K) *value[V] {
+	s := m.shardFor(key)
+	if result := s.lookup(key); result != nil {
+		return result
+	}
+
+	s.mx.Lock()
+	result, created := m.valueIn(s, key)
+	s.missed()
+	s.mx.Unlock()
 
-	result := m.m[key]
+	if created {
+		m.created()
+	}
+	return result
+}
+
+// valueIn returns the value at key in shard s, creating it if necessary, and whether it did. Must be
+// called while holding the shard's lock. If it created the value, the caller must call created
+// after releasing the lock.
+func (m *emap[K, V]) valueIn(s *shard[K, V], key K) (*value[V], bool) {
+	result := s.m[key]
+	created := false
 	if result == nil {
-		result = NewValue[V]()
-		m.m[key] = result
+		if m.closed.Load() {
+			return m.closedValue, false
+		}
+		created = true
+		result = m.newValue(key)
+		result.onUpdate = func(u Update[V]) {
+			m.publish(key, u)
+		}
+		result.watched = m.watched
+		s.m[key] = result
+		if m.onCreate != nil {
+			m.onCreate(key)
+		}
 	}
+	return result, created
+}
 
+// newValue creates a Value for key with the Map's options and the key's default, if any, without
+// adding it to the Map.
+func (m *emap[K, V]) newValue(key K) *value[V] {
+	result := newValueExact[V](m.opts)
+	if defaultValue, found := m.defaults[key]; found {
+		result.defaultValue = defaultValue
+		result.hasDefault = true
+	}
 	return result
 }
+
+func (m *emap[K, V]) ExpiryHistogram(buckets []time.Duration) []int {
+	counts := make([]int, len(buckets)+1)
+	now := m.clock.Now()
+	for _, s := range m.shards {
+		s.mx.Lock()
+		for _, v := range s.m {
+			snap := v.snapshot()
+			if !snap.valid(now) {
+				continue
+			}
+			remaining := snap.expiration.Sub(now)
+			counts[sort.Search(len(buckets), func(i int) bool {
+				return remaining <= buckets[i]
+			})]++
+		}
+		s.mx.Unlock()
+	}
+	return counts
+}
+
+func (m *emap[K, V]) StatsByClass() map[string]Stats {
+	result := make(map[string]Stats)
+	for _, s := range m.shards {
+		s.mx.Lock()
+		for key, v := range s.m {
+			class := ""
+			if m.classify != nil {
+				class = m.classify(key)
+			}
+			stats := result[class]
+			stats.Keys++
+			stats.Bytes += v.Weight()
+			result[class] = stats
+		}
+		s.mx.Unlock()
+	}
+	return result
+}
+
+func (m *emap[K, V]) ShardOccupancy() []int {
+	occupancy := make([]int, len(m.shards))
+	for i, s := range m.shards {
+		s.mx.Lock()
+		occupancy[i] = len(s.m)
+		s.mx.Unlock()
+	}
+	return occupancy
+}
+
+func (m *emap[K, V]) Stats() Stats {
+	var stats Stats
+	for _, s := range m.shards {
+		s.mx.Lock()
+		stats.Keys += len(s.m)
+		for _, v := range s.m {
+			stats.Bytes += v.Weight()
+		}
+		s.mx.Unlock()
+	}
+	return stats
+}
+
+// String implements fmt.Stringer, summarizing the Map for debugging. See Dump for the details.
+func (m *emap[K, V]) String() string {
+	keys, set, pending := 0, 0, 0
+	now := m.clock.Now()
+	for _, s := range m.shards {
+		s.mx.Lock()
+		keys += len(s.m)
+		for _, v := range s.m {
+			if v.snapshot().valid(now) {
+				set++
+			}
+			if v.Waiters() > 0 {
+				pending++
+			}
+		}
+		s.mx.Unlock()
+	}
+	return fmt.Sprintf("Map(%d keys, %d set, %d pending)", keys, set, pending)
+}
+
+func (m *emap[K, V]) Dump(w io.Writer) error {
+	type line struct {
+		key   string
+		value string
+	}
+	var lines []line
+	for _, s := range m.shards {
+		s.mx.Lock()
+		for key, v := range s.m {
+			lines = append(lines, line{fmt.Sprint(key), v.String()})
+		}
+		s.mx.Unlock()
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].key < lines[j].key
+	})
+	for _, l := range lines {
+		if _, err := fmt.Fprintf(w, "%v: %v\n", l.key, l.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
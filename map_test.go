@@ -1,7 +1,15 @@
 package eventual
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -24,3 +32,535 @@ func TestMap(t *testing.T) {
 	require.Error(t, err)
 	require.Equal(t, 0, c)
 }
+
+func TestGetOrWait(t *testing.T) {
+	m := NewMap[string, int]()
+	_, err := m.GetOrWait(context.Background(), "a")
+	require.Equal(t, ErrNoSuchKey, err)
+	require.Equal(t, 0, m.Stats().Keys, "GetOrWait shouldn't track unknown keys")
+
+	m.Reset("a")
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.Set("a", 1)
+	}()
+	a, err := m.GetOrWait(context.Background(), "a")
+	require.NoError(t, err)
+	require.Equal(t, 1, a, "GetOrWait should wait for known keys")
+}
+
+func TestTryGetMany(t *testing.T) {
+	m := NewMap[string, int]()
+	m.Set("a", 1)
+	m.Set("c", 3)
+	m.Reset("d")
+
+	found, missing := m.TryGetMany("a", "b", "c", "d")
+	require.Equal(t, map[string]int{"a": 1, "c": 3}, found)
+	require.Equal(t, []string{"b", "d"}, missing)
+	require.Equal(t, 3, m.Stats().Keys, "TryGetMany shouldn't track unknown keys")
+}
+
+func TestSetManyVisibility(t *testing.T) {
+	m := NewMap[int, int](WithShards(16))
+	for round := 0; round < 50; round++ {
+		entries := make(map[int]int, 100)
+		for i := 0; i < 100; i++ {
+			entries[i] = round
+		}
+		m.SetMany(entries)
+
+		// Readers that start after SetMany returns must see the complete batch in every shard
+		const readers = 4
+		errs := make(chan error, readers)
+		for r := 0; r < readers; r++ {
+			go func() {
+				for i := 0; i < 100; i++ {
+					v, err := m.Get(DontWait, i)
+					if err == nil && v != round {
+						err = fmt.Errorf("key %d is %d", i, v)
+					}
+					if err != nil {
+						errs <- err
+						return
+					}
+				}
+				errs <- nil
+			}()
+		}
+		for r := 0; r < readers; r++ {
+			require.NoError(t, <-errs, "round %d", round)
+		}
+	}
+}
+
+func TestMapExpireAll(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMap[int, int](WithClock(clock))
+	for i := 0; i < 100; i++ {
+		m.Set(i, i)
+	}
+	m.SetExpiring(100, 100, clock.Now())
+	at := clock.Now().Add(time.Minute)
+	require.Equal(t, 100, m.ExpireAll(at, time.Minute), "expired value shouldn't be moved")
+
+	distinct := make(map[time.Time]bool)
+	for i := 0; i < 100; i++ {
+		expiration, ok := m.(*emap[int, int]).getValue(i).Expiration()
+		require.True(t, ok)
+		require.False(t, expiration.Before(at))
+		require.True(t, expiration.Before(at.Add(time.Minute)))
+		distinct[expiration] = true
+	}
+	require.Greater(t, len(distinct), 1, "expirations should be staggered")
+
+	clock.Advance(2 * time.Minute)
+	found, _ := m.TryGetMany(1, 2, 3)
+	require.Empty(t, found)
+	require.Zero(t, m.ExpireAll(at, 0))
+}
+
+func TestMapStats(t *testing.T) {
+	m := NewMap[string, string](WithWeigher(func(s string) int { return len(s) }))
+	m.Set("a", "one")
+	m.Set("b", "three")
+	m.Reset("c")
+
+	stats := m.Stats()
+	require.Equal(t, 3, stats.Keys)
+	require.Equal(t, 8, stats.Bytes)
+}
+
+func TestExpiryHistogram(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMap[string, int](WithClock(clock))
+	m.SetExpiring("a", 1, clock.Now().Add(30*time.Second))
+	m.SetExpiring("b", 2, clock.Now().Add(time.Minute))
+	m.SetExpiring("c", 3, clock.Now().Add(90*time.Second))
+	m.SetExpiring("expired", 4, clock.Now())
+	m.Set("d", 5)
+	m.Reset("e")
+
+	buckets := []time.Duration{time.Minute, 5 * time.Minute}
+	require.Equal(t, []int{2, 1, 1}, m.ExpiryHistogram(buckets))
+	clock.Advance(time.Minute)
+	require.Equal(t, []int{1, 0, 1}, m.ExpiryHistogram(buckets))
+}
+
+func TestMapStatsByClass(t *testing.T) {
+	m := NewMap[string, string](
+		WithWeigher(func(s string) int { return len(s) }),
+		WithKeyClassifier(func(key string) string { return strings.SplitN(key, ":", 2)[0] }),
+	)
+	m.Set("user:1", "one")
+	m.Set("user:2", "three")
+	m.Set("org:1", "acme")
+	m.Reset("token:1")
+
+	require.Equal(t, map[string]Stats{
+		"user":  {Keys: 2, Bytes: 8},
+		"org":   {Keys: 1, Bytes: 4},
+		"token": {Keys: 1, Bytes: 0},
+	}, m.StatsByClass())
+
+	unclassified := NewMap[string, string]()
+	unclassified.Set("a", "A")
+	require.Equal(t, map[string]Stats{"": {Keys: 1}}, unclassified.StatsByClass())
+}
+
+func TestMapShards(t *testing.T) {
+	m := NewMap[int, int](WithShards(5), WithShardFn(func(k int) uint32 { return uint32(k) })).(*emap[int, int])
+	require.Len(t, m.shards, 8, "shard count should be rounded up to a power of two")
+	for i := 0; i < 16; i++ {
+		m.Set(i, i)
+	}
+	for _, s := range m.shards {
+		require.Len(t, s.m, 2, "custom shard fn should place keys evenly")
+	}
+	for i := 0; i < 16; i++ {
+		r, err := m.Get(DontWait, i)
+		require.NoError(t, err)
+		require.Equal(t, i, r)
+	}
+
+	require.Len(t, NewMap[string, int]().(*emap[string, int]).shards, defaultShards)
+	require.Len(t, NewMap[string, int](WithShards(1)).(*emap[string, int]).shards, 1)
+
+	type pair struct{ a, b string }
+	pm := NewMap[pair, int]()
+	pm.Set(pair{"x", "y"}, 1)
+	r, err := pm.Get(DontWait, pair{"x", "y"})
+	require.NoError(t, err)
+	require.Equal(t, 1, r)
+}
+
+func TestMapEqualKeysShareShard(t *testing.T) {
+	negZero := math.Copysign(0, -1)
+	fm := NewMap[float64, int](WithShards(64))
+	fm.Set(0.0, 1)
+	r, err := fm.Get(DontWait, negZero)
+	require.NoError(t, err, "-0 should find the value set at 0")
+	require.Equal(t, 1, r)
+	require.Equal(t, 1, fm.Stats().Keys)
+
+	type point struct {
+		x, y float64
+		name interface{}
+	}
+	pm := NewMap[point, int](WithShards(64))
+	pm.Set(point{0, 1, 0.0}, 1)
+	r, err = pm.Get(DontWait, point{negZero, 1, negZero})
+	require.NoError(t, err, "floats in struct keys should be normalized")
+	require.Equal(t, 1, r)
+	require.Equal(t, 1, pm.Stats().Keys)
+}
+
+func TestMapShardSkew(t *testing.T) {
+	var reports [][]int
+	skewed := true
+	m := NewMap[int, int](
+		WithShards(4),
+		WithShardFn(func(k int) uint32 {
+			if skewed {
+				return 0
+			}
+			return uint32(k)
+		}),
+		WithSkewHandler(2, func(occupancy []int) { reports = append(reports, occupancy) }),
+	)
+	for i := 0; i < 8; i++ {
+		m.Set(i, i)
+	}
+	require.Equal(t, []int{8, 0, 0, 0}, m.ShardOccupancy())
+	require.Equal(t, [][]int{{4, 0, 0, 0}}, reports, "skew should only be reported once while it lasts")
+
+	skewed = false
+	for i := 9; i <= 32; i++ {
+		m.Set(i, i)
+	}
+	require.Equal(t, []int{14, 6, 6, 6}, m.ShardOccupancy())
+	skewed = true
+	for i := 100; i < 108; i++ {
+		m.Set(i, i)
+	}
+	require.Len(t, reports, 2, "skew should be reported again after it went away")
+}
+
+func BenchmarkMapGetContended(b *testing.B) {
+	const numKeys = 1024
+	keys := make([]string, numKeys)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+
+	for _, shards := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			m := NewMap[string, int](WithShards(shards))
+			for i, key := range keys {
+				m.Set(key, i)
+			}
+			ctx := context.Background()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					m.Get(ctx, keys[i%numKeys])
+					i++
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkMapSkewedKeys(b *testing.B) {
+	// All keys share a long prefix and differ only at the end, a common pathological pattern.
+	const numKeys = 1024
+	keys := make([]string, numKeys)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("tenant/0000000000/resource/%d", i)
+	}
+
+	for name, opt := range map[string]Option{
+		"default": WithShards(16),
+		"prefixFn": WithShardFn(func(k string) uint32 {
+			return uint32(k[0])
+		}),
+	} {
+		b.Run(name, func(b *testing.B) {
+			m := NewMap[string, int](opt)
+			for i, key := range keys {
+				m.Set(key, i)
+			}
+			ctx := context.Background()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					m.Get(ctx, keys[i%numKeys])
+					i++
+				}
+			})
+		})
+	}
+}
+
+func TestMapReadIndex(t *testing.T) {
+	m := NewMap[string, int](WithShards(1)).(*emap[string, int])
+	s := m.shards[0]
+	m.Set("a", 1)
+	m.Set("b", 2)
+	require.NotNil(t, s.lookup("a"), "index should have been published once misses caught up")
+	require.Nil(t, s.lookup("c"))
+
+	// Keys that aren't in the index yet are still found via the locked map
+	m.Set("c", 3)
+	r, err := m.Get(DontWait, "c")
+	require.NoError(t, err)
+	require.Equal(t, 3, r)
+}
+
+func TestMapConcurrentReadsAndWrites(t *testing.T) {
+	const numKeys = 100
+	m := NewMap[int, int](WithShards(4))
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < numKeys; i++ {
+				m.Set(i, i)
+			}
+		}()
+	}
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < numKeys; i++ {
+				v, err := m.Get(context.Background(), i)
+				require.NoError(t, err)
+				require.Equal(t, i, v)
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, numKeys, m.Stats().Keys)
+}
+
+func BenchmarkMapGetExisting(b *testing.B) {
+	m := NewMap[string, int]()
+	m.Set("key", 1)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			m.Get(ctx, "key")
+		}
+	})
+}
+
+func BenchmarkMapSet(b *testing.B) {
+	m := NewMap[int, int]()
+	for i := 0; i < b.N; i++ {
+		m.Set(i&1023, i)
+	}
+}
+
+func TestGetAllWithin(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMap[string, int](WithClock(clock))
+	m.Set("ready", 1)
+
+	type result struct {
+		found map[string]int
+		errs  map[string]error
+	}
+	done := make(chan result)
+	go func() {
+		found, errs := m.GetAllWithin(context.Background(), map[string]time.Duration{
+			"ready":   10 * time.Millisecond,
+			"slow":    200 * time.Millisecond,
+			"tooSlow": 10 * time.Millisecond,
+			"never":   50 * time.Millisecond,
+		})
+		done <- result{found, errs}
+	}()
+	waitPending := func(key string) {
+		require.Eventually(t, func() bool {
+			pending := false
+			m.ForEachPending(func(k string, _ int, _ time.Duration) {
+				pending = pending || k == key
+			})
+			return pending
+		}, time.Second, time.Millisecond)
+	}
+
+	waitPending("tooSlow")
+	clock.Advance(10 * time.Millisecond)
+	waitPending("never")
+	clock.Advance(20 * time.Millisecond)
+	m.Set("slow", 2)
+	m.Set("tooSlow", 3)
+	clock.Advance(20 * time.Millisecond)
+
+	r := <-done
+	require.Equal(t, map[string]int{"ready": 1, "slow": 2}, r.found)
+	require.Len(t, r.errs, 2)
+	require.True(t, errors.Is(r.errs["tooSlow"], context.DeadlineExceeded))
+	require.True(t, errors.Is(r.errs["never"], context.DeadlineExceeded))
+}
+
+func TestMapWithDefaults(t *testing.T) {
+	defaults := map[string]string{"flag": "off"}
+	m := NewMapWithDefaults(defaults)
+	defaults["flag"] = "mutated"
+
+	r, err := m.Get(DontWait, "flag")
+	require.NoError(t, err)
+	require.Equal(t, "off", r, "listed key should fall back to its default")
+
+	_, err = m.Get(DontWait, "other")
+	require.Error(t, err, "unlisted key should behave normally")
+
+	m.Set("flag", "on")
+	r, err = m.Get(DontWait, "flag")
+	require.NoError(t, err)
+	require.Equal(t, "on", r)
+}
+
+func TestWaitAllSet(t *testing.T) {
+	m := NewMapWithDefaults(map[string]int{"b": -1})
+	m.Set("a", 1)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.Set("b", 2)
+		m.Set("c", 3)
+	}()
+	require.NoError(t, m.WaitAllSet(context.Background(), "a", "b", "c"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	m.Reset("b")
+	require.Equal(t, context.DeadlineExceeded, m.WaitAllSet(ctx, "a", "b"), "default shouldn't count as set")
+}
+
+func TestWaitCount(t *testing.T) {
+	m := NewMap[int, int]()
+	m.Set(0, 0)
+	require.NoError(t, m.WaitCount(DontWait, 1))
+	require.Equal(t, context.Canceled, m.WaitCount(DontWait, 2))
+
+	for i := 1; i < 5; i++ {
+		go func(i int) {
+			time.Sleep(time.Duration(i) * 5 * time.Millisecond)
+			m.Set(i, i)
+		}(i)
+	}
+	require.NoError(t, m.WaitCount(context.Background(), 5))
+}
+
+func TestForEachPending(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMap[string, int](WithClock(clock))
+	m.Set("ready", 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	wait := func(key string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Get(ctx, key)
+		}()
+	}
+	waitFor := func(expected map[string]int) map[string]time.Duration {
+		var ages map[string]time.Duration
+		require.Eventually(t, func() bool {
+			counts := make(map[string]int)
+			ages = make(map[string]time.Duration)
+			m.ForEachPending(func(key string, waiters int, oldest time.Duration) {
+				counts[key] = waiters
+				ages[key] = oldest
+			})
+			return fmt.Sprint(counts) == fmt.Sprint(expected)
+		}, time.Second, time.Millisecond)
+		return ages
+	}
+
+	wait("stuck")
+	wait("ready")
+	waitFor(map[string]int{"stuck": 1})
+	clock.Advance(time.Minute)
+	wait("stuck")
+	wait("other")
+	ages := waitFor(map[string]int{"stuck": 2, "other": 1})
+	require.Equal(t, time.Minute, ages["stuck"], "age of oldest waiter should be reported")
+	require.Equal(t, time.Duration(0), ages["other"])
+
+	m.Set("other", 2)
+	waitFor(map[string]int{"stuck": 2})
+	cancel()
+	wg.Wait()
+	waitFor(map[string]int{})
+}
+
+func TestMapDump(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMap[string, int](WithClock(clock))
+	m.SetExpiring("b", 2, clock.Now().Add(time.Minute))
+	m.Set("a", 1)
+	m.Reset("c")
+	require.Equal(t, "Map(3 keys, 2 set, 0 pending)", fmt.Sprint(m))
+
+	var buf bytes.Buffer
+	require.NoError(t, m.Dump(&buf))
+	require.Equal(t, "a: int(len=1, hash=340ca71c)\nb: int(len=1, hash=370cabd5) (expires in 1m0s)\nc: <unset>\n", buf.String())
+
+	visible := NewMap[string, int](WithVisiblePayloads())
+	visible.Set("a", 1)
+	buf.Reset()
+	require.NoError(t, visible.Dump(&buf))
+	require.Equal(t, "a: 1\n", buf.String())
+}
+
+func TestMapPeekAndTryGet(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMapWithDefaults(map[string]int{"default": 5}, WithClock(clock))
+	_, ok := m.Peek("a")
+	require.False(t, ok)
+	require.Zero(t, m.Stats().Keys, "Peek shouldn't start tracking keys")
+	_, err := m.TryGet("a")
+	require.True(t, errors.Is(err, ErrNotSet))
+	require.False(t, errors.Is(err, context.Canceled), "TryGet shouldn't report the context's error")
+	require.Zero(t, m.Stats().Keys, "TryGet shouldn't start tracking keys")
+
+	m.SetExpiring("a", 1, clock.Now().Add(time.Second))
+	r, ok := m.Peek("a")
+	require.True(t, ok)
+	require.Equal(t, 1, r)
+	r, err = m.TryGet("a")
+	require.NoError(t, err)
+	require.Equal(t, 1, r)
+
+	clock.Advance(time.Second)
+	_, err = m.TryGet("a")
+	require.True(t, errors.Is(err, ErrExpired))
+	fail := errors.New("fail")
+	m.SetError("a", fail)
+	_, err = m.TryGet("a")
+	require.True(t, errors.Is(err, fail))
+
+	_, ok = m.Peek("default")
+	require.False(t, ok, "Peek should ignore defaults")
+	r, err = m.TryGet("default")
+	require.NoError(t, err)
+	require.Equal(t, 5, r)
+	require.Equal(t, 1, m.Stats().Keys)
+
+	m.Close()
+	_, err = m.TryGet("b")
+	require.Equal(t, ErrClosed, err)
+}
@@ -0,0 +1,238 @@
+package eventual
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Option configures a Value or Map at construction time. Options passed to a Map apply to every
+// Value it holds.
+type Option func(*options)
+
+type options struct {
+	weigher interface{}
+	shards  int
+	shardFn interface{}
+	misuse  func(error)
+
+	comparator interface{}
+	clock      Clock
+	fallbacks  []interface{}
+	codec      interface{}
+	keyCodec   interface{}
+
+	computeGrace   time.Duration
+	notifyInterval time.Duration
+	diagnostics    bool
+	maxAge         time.Duration
+	classifier     interface{}
+	quorum         int
+	reducer        interface{}
+	history        int
+	skewThreshold  float64
+	onSkew         func([]int)
+	maxPending     int
+
+	defaultOnImmediate bool
+	defaultReporter    *defaultReporter
+	jsonExpiration     bool
+	visiblePayloads    bool
+	sensitive          bool
+	audit              func(UpdateKind, time.Time, string)
+}
+
+// defaults holds the options set with SetDefaults.
+var defaults atomic.Pointer[[]Option]
+
+// SetDefaults configures options that are applied to every Value and Map created afterwards,
+// before the options passed to the constructor, which take precedence. This allows applications to
+// enforce defaults, such as a Clock or a misuse handler, without touching every place that creates
+// a Value. Each call replaces the defaults set by the previous one. Values and Maps that already
+// exist are unaffected, including the Values a Map creates for new keys.
+//
+// Options that depend on the key or value type, such as WithWeigher, WithComparator, WithFallback,
+// WithQuorum, WithShardFn, WithKeyClassifier, WithCodec and WithKeyCodec, can't be defaults, since
+// they'd break every Value and Map of a different type, including the ones this package creates
+// internally. SetDefaults panics if given one.
+func SetDefaults(opts ...Option) {
+	if typed := buildOptions(opts).typed(); len(typed) > 0 {
+		panic(fmt.Sprintf("eventual: SetDefaults doesn't accept type-specific options: %v", strings.Join(typed, ", ")))
+	}
+	opts = append([]Option(nil), opts...)
+	defaults.Store(&opts)
+}
+
+// typed returns the names of the type-specific options that are set.
+func (o *options) typed() []string {
+	var names []string
+	for _, opt := range []struct {
+		name string
+		set  bool
+	}{
+		{"WithWeigher", o.weigher != nil},
+		{"WithShardFn", o.shardFn != nil},
+		{"WithComparator", o.comparator != nil},
+		{"WithFallback", len(o.fallbacks) > 0},
+		{"WithCodec", o.codec != nil},
+		{"WithKeyCodec", o.keyCodec != nil},
+		{"WithKeyClassifier", o.classifier != nil},
+		{"WithQuorum", o.quorum > 0 || o.reducer != nil},
+	} {
+		if opt.set {
+			names = append(names, opt.name)
+		}
+	}
+	return names
+}
+
+// withDefaults returns opts preceded by the defaults set with SetDefaults.
+func withDefaults(opts []Option) []Option {
+	d := defaults.Load()
+	if d == nil || len(*d) == 0 {
+		return opts
+	}
+	return append(append(make([]Option, 0, len(*d)+len(opts)), *d...), opts...)
+}
+
+func buildOptions(opts []Option) *options {
+	o := &options{clock: SystemClock}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithWeigher configures a function that reports the approximate size in bytes of a payload. The
+// weigher must accept the Value's (or Map's) value type. It's called once per Set and its result is
+// reported via Value.Weight and Map.Stats, which helps attribute heap growth to specific caches.
+func WithWeigher[V comparable](weigher func(V) int) Option {
+	return func(o *options) {
+		o.weigher = weigher
+	}
+}
+
+// WithComparator configures the function used to decide whether two values are equal, for
+// example in Value.EqualTo. It must accept the Value's (or Map's) value type. By default, values
+// are compared with ==.
+func WithComparator[V comparable](equal func(a, b V) bool) Option {
+	return func(o *options) {
+		o.comparator = equal
+	}
+}
+
+// WithFallback configures another Value that's consulted, without waiting, when a Get times out
+// before a value is available, for example one holding the last known good value loaded from disk.
+// It must have the same value type. The fallback is consulted before the default, if any (see
+// WithDefault). Multiple fallbacks are consulted in the order they were given. Fallbacks must not
+// form a cycle.
+func WithFallback[V comparable](fallback Value[V]) Option {
+	return func(o *options) {
+		o.fallbacks = append(o.fallbacks, fallback)
+	}
+}
+
+// WithMisuseHandler configures a function that's called with the reason whenever a write to a
+// Value is dropped, for example because the Value was frozen. Production code might log these,
+// while tests can fail loudly. By default, dropped writes aren't reported.
+func WithMisuseHandler(handler func(err error)) Option {
+	return func(o *options) {
+		o.misuse = handler
+	}
+}
+
+// WithComputeGrace configures how long Value.GetOrCompute waits for a value to be set before it
+// starts computing one itself. By default, it starts computing right away.
+func WithComputeGrace(grace time.Duration) Option {
+	return func(o *options) {
+		o.computeGrace = grace
+	}
+}
+
+// WithMaxNotifyRate limits the iterators returned by Value.Updates and Map.Events to delivering
+// updates at most perSecond times per second, protecting slow consumers from high-frequency
+// producers. Updates that arrive in between are coalesced as usual, with the latest value winning.
+// Each delivery from Map.Events yields the latest update for every key that changed. By default,
+// updates are delivered as fast as the consumer takes them.
+func WithMaxNotifyRate(perSecond float64) Option {
+	return func(o *options) {
+		o.notifyInterval = 0
+		if perSecond > 0 {
+			o.notifyInterval = time.Duration(float64(time.Second) / perSecond)
+		}
+	}
+}
+
+// WithTimeoutDiagnostics makes the errors that Get returns when no value is available in time
+// include a description of the Value's state, such as whether it was ever set and whether a
+// refresh is in flight. See TimeoutError.
+func WithTimeoutDiagnostics() Option {
+	return func(o *options) {
+		o.diagnostics = true
+	}
+}
+
+// WithMaxAgePolicy caps the lifetime of every value at maxAge from when it was set, whatever
+// expiration producers pass to SetExpiring and friends. This enforces freshness limits centrally
+// instead of at every call site.
+func WithMaxAgePolicy(maxAge time.Duration) Option {
+	return func(o *options) {
+		o.maxAge = maxAge
+	}
+}
+
+// WithKeyClassifier configures a function that assigns each key of a Map to a class, such as
+// "user", "org" or "token", so that Map.StatsByClass can break statistics down by class without a
+// label per key. It must accept the Map's key type.
+func WithKeyClassifier[K comparable](classify func(K) string) Option {
+	return func(o *options) {
+		o.classifier = classify
+	}
+}
+
+// DefaultOnImmediateGet makes a Get whose context is already done when it's called, such as
+// Get(DontWait), return the Value's default (see WithDefault and WithDefaultFunc) whenever no valid
+// value is set, instead of consulting fallbacks or returning the error the Value failed with.
+// Non-blocking reads then deterministically get either the current value or the default. It has
+// no effect on Values without a default.
+func DefaultOnImmediateGet() Option {
+	return func(o *options) {
+		o.defaultOnImmediate = true
+	}
+}
+
+// WithDefaultFallbackHandler configures a function that's called when a Get returns the default
+// (see WithDefault and WithDefaultFunc) because no value was available in time, so that a broken
+// producer doesn't go unnoticed. It's called with the given name and how long the Get waited.
+// Calls are throttled to at most one per interval, shared by every Value configured with the same
+// Option, such as all the Values in a Map, and suppressed is the number of fallbacks that weren't
+// reported since the previous call. handler must not block.
+func WithDefaultFallbackHandler(name string, interval time.Duration, handler func(name string, waited time.Duration, suppressed int)) Option {
+	r := &defaultReporter{name: name, interval: interval, handler: handler}
+	return func(o *options) {
+		o.defaultReporter = r
+	}
+}
+
+// WithVisiblePayloads makes the String method of Values, and therefore Map.Dump, include payloads,
+// abbreviated if they're long, instead of only summarizing them. Only use it for Values that never
+// hold credentials or other sensitive data. Secrets (see NewSecret) are redacted regardless.
+func WithVisiblePayloads() Option {
+	return func(o *options) {
+		o.visiblePayloads = true
+	}
+}
+
+// typedOption returns opt as a T, panicking if it was configured with a func for a different type.
+func typedOption[T any](name string, opt interface{}) T {
+	var result T
+	if opt == nil {
+		return result
+	}
+	result, ok := opt.(T)
+	if !ok {
+		panic(fmt.Sprintf("eventual: %v requires a %T, not a %T", name, result, opt))
+	}
+	return result
+}
@@ -0,0 +1,43 @@
+package eventual
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDefaults(t *testing.T) {
+	t.Cleanup(func() { SetDefaults() })
+	clock := newFakeClock()
+	SetDefaults(WithClock(clock))
+
+	v := NewValue[int]()
+	v.SetExpiring(1, clock.Now().Add(time.Second))
+	clock.Advance(time.Second)
+	require.True(t, v.IsExpired(), "value should use the default clock")
+
+	other := newFakeClock()
+	v = NewValue[int](WithClock(other))
+	v.SetExpiring(1, other.Now().Add(time.Second))
+	clock.Advance(time.Second)
+	require.False(t, v.IsExpired(), "options passed to the constructor should take precedence")
+
+	m := NewMap[string, int]()
+	SetDefaults()
+	m.SetExpiring("a", 1, clock.Now().Add(time.Second))
+	clock.Advance(time.Second)
+	_, err := m.Get(DontWait, "a")
+	require.Error(t, err, "keys created after the defaults changed should still use the Map's defaults")
+}
+
+func TestSetDefaultsRejectsTypedOptions(t *testing.T) {
+	t.Cleanup(func() { SetDefaults() })
+	require.Panics(t, func() {
+		SetDefaults(WithClock(newFakeClock()), WithWeigher(func(s string) int { return len(s) }))
+	})
+	require.NotPanics(t, func() {
+		NewValue[int]()
+		NewAnyValue[[]int]()
+	}, "rejected defaults shouldn't affect other types")
+}
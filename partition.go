@@ -0,0 +1,115 @@
+package eventual
+
+import (
+	"context"
+	"iter"
+	"sync"
+)
+
+// PartitionedMap is a Map whose keys are grouped by their prefix (see Prefixed), for example by
+// tenant, and that supports operations on whole partitions at a cost proportional to the size of
+// the partition rather than of the Map.
+type PartitionedMap[P comparable, K interface {
+	comparable
+	Prefixed[P]
+}, V comparable] interface {
+	Map[K, V]
+
+	// CountByPrefix returns the number of keys with the given prefix that currently hold an
+	// unexpired value.
+	CountByPrefix(prefix P) int
+
+	// ClearPrefix resets every key with the given prefix that currently holds a value, returning
+	// the number of keys it reset. Like Reset, it doesn't remove the keys from the Map: callers may
+	// be waiting for them or about to write to them, so the Map keeps tracking them, and they count
+	// towards CountByPrefix again once they're set.
+	ClearPrefix(prefix P) int
+
+	// WatchPrefix is like Events, but only yields updates to keys with the given prefix.
+	WatchPrefix(ctx context.Context, prefix P) iter.Seq2[K, Update[V]]
+}
+
+// NewPartitionedMap creates a new PartitionedMap. The given options are applied to every Value in
+// the Map.
+func NewPartitionedMap[P comparable, K interface {
+	comparable
+	Prefixed[P]
+}, V comparable](opts ...Option) PartitionedMap[P, K, V] {
+	m := &partitionedMap[P, K, V]{
+		emap:       NewMap[K, V](opts...).(*emap[K, V]),
+		partitions: make(map[P]map[K]struct{}),
+	}
+	m.emap.onCreate = m.index
+	return m
+}
+
+type partitionedMap[P comparable, K interface {
+	comparable
+	Prefixed[P]
+}, V comparable] struct {
+	*emap[K, V]
+	mx         sync.RWMutex
+	partitions map[P]map[K]struct{}
+}
+
+// index adds a newly tracked key to its partition.
+func (m *partitionedMap[P, K, V]) index(key K) {
+	prefix := key.Prefix()
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	partition := m.partitions[prefix]
+	if partition == nil {
+		partition = make(map[K]struct{})
+		m.partitions[prefix] = partition
+	}
+	partition[key] = struct{}{}
+}
+
+// partition returns the values of the keys with the given prefix.
+func (m *partitionedMap[P, K, V]) partition(prefix P) []*value[V] {
+	m.mx.RLock()
+	keys := make([]K, 0, len(m.partitions[prefix]))
+	for key := range m.partitions[prefix] {
+		keys = append(keys, key)
+	}
+	m.mx.RUnlock()
+
+	values := make([]*value[V], 0, len(keys))
+	for _, key := range keys {
+		if v := m.existingValue(key); v != nil {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func (m *partitionedMap[P, K, V]) CountByPrefix(prefix P) int {
+	count := 0
+	for _, v := range m.partition(prefix) {
+		if v.IsSet() {
+			count++
+		}
+	}
+	return count
+}
+
+func (m *partitionedMap[P, K, V]) ClearPrefix(prefix P) int {
+	cleared := 0
+	for _, v := range m.partition(prefix) {
+		if v.IsSet() {
+			v.Reset()
+			cleared++
+		}
+	}
+	return cleared
+}
+
+func (m *partitionedMap[P, K, V]) WatchPrefix(ctx context.Context, prefix P) iter.Seq2[K, Update[V]] {
+	return func(yield func(K, Update[V]) bool) {
+		for key, u := range m.Events(ctx) {
+			if key.Prefix() == prefix && !yield(key, u) {
+				return
+			}
+		}
+	}
+}
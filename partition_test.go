@@ -0,0 +1,51 @@
+package eventual
+
+import (
+	"context"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionedMap(t *testing.T) {
+	m := NewPartitionedMap[string, Key2[string, int], string]()
+	m.Set(NewKey2("acme", 1), "one")
+	m.Set(NewKey2("acme", 2), "two")
+	m.Reset(NewKey2("acme", 3))
+	m.Set(NewKey2("other", 1), "one")
+
+	require.Equal(t, 2, m.CountByPrefix("acme"))
+	require.Equal(t, 1, m.CountByPrefix("other"))
+	require.Zero(t, m.CountByPrefix("unknown"))
+
+	keys := m.Stats().Keys
+	require.Equal(t, 2, m.ClearPrefix("acme"))
+	require.Zero(t, m.CountByPrefix("acme"))
+	require.Equal(t, keys, m.Stats().Keys, "cleared keys should still be tracked")
+	require.Equal(t, 1, m.CountByPrefix("other"), "other partitions should be untouched")
+	_, err := m.Get(DontWait, NewKey2("acme", 1))
+	require.Error(t, err)
+
+	m.Set(NewKey2("acme", 1), "again")
+	require.Equal(t, 1, m.CountByPrefix("acme"), "cleared keys should count again once they're set")
+}
+
+func TestWatchPrefix(t *testing.T) {
+	m := NewPartitionedMap[string, Key2[string, int], string]()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	next, stop := iter.Pull2(m.WatchPrefix(ctx, "acme"))
+	defer stop()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.Set(NewKey2("other", 1), "ignored")
+		m.Set(NewKey2("acme", 1), "one")
+	}()
+	key, u, ok := next()
+	require.True(t, ok)
+	require.Equal(t, NewKey2("acme", 1), key)
+	require.Equal(t, "one", u.Value)
+}
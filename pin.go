@@ -0,0 +1,46 @@
+package eventual
+
+import (
+	"sync"
+)
+
+func (v *value[V]) Pin() (V, func(), error) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	if err := v.unavailable(); err != nil {
+		return v.zeroValue, nil, err
+	}
+	s := v.snapshot()
+
+	v.pins++
+	if v.pins == 1 {
+		v.published.Store(&snapshot[V]{v: s.v, expiration: s.expiration, softExpiration: s.softExpiration, since: s.since, pinned: true})
+	}
+	version := v.Version()
+	var once sync.Once
+	return s.v, func() {
+		once.Do(func() { v.unpin(version) })
+	}, nil
+}
+
+// unpin releases a pin on the value at the given version.
+func (v *value[V]) unpin(version uint64) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	if v.Version() != version {
+		// The pinned value isn't current anymore
+		return
+	}
+	v.pins--
+	if v.pins > 0 {
+		return
+	}
+	s := v.snapshot()
+	v.published.Store(&snapshot[V]{v: s.v, expiration: v.expiration, softExpiration: s.softExpiration, since: s.since})
+	if v.expiry != nil {
+		v.scheduleExpiry()
+	}
+	if v.lapse != nil {
+		v.scheduleLapse()
+	}
+}
@@ -0,0 +1,90 @@
+package eventual
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPin(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	_, unpin, err := v.Pin()
+	require.True(t, errors.Is(err, ErrNotSet))
+	require.Nil(t, unpin)
+
+	v.SetExpiring("a", clock.Now().Add(time.Second))
+	expiry := v.NotifyExpiry()
+	r, unpin1, err := v.Pin()
+	require.NoError(t, err)
+	require.Equal(t, "a", r)
+	_, unpin2, err := v.Pin()
+	require.NoError(t, err)
+
+	clock.Advance(2 * time.Second)
+	r, err = v.Get(DontWait)
+	require.NoError(t, err, "pinned value shouldn't expire")
+	require.Equal(t, "a", r)
+	requireOpen(t, expiry, "pinned value shouldn't expire")
+
+	unpin1()
+	unpin1()
+	_, err = v.Get(DontWait)
+	require.NoError(t, err, "value should remain pinned until the last unpin")
+	unpin2()
+	_, err = v.Get(DontWait)
+	require.True(t, errors.Is(err, ErrExpired), "value should expire once unpinned")
+	clock.Advance(0)
+	requireClosed(t, expiry, "expiry should be notified once unpinned")
+	_, _, err = v.Pin()
+	require.True(t, errors.Is(err, ErrExpired))
+}
+
+func TestPinReplaced(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	v.SetExpiring("a", clock.Now().Add(time.Second))
+	_, unpin, err := v.Pin()
+	require.NoError(t, err)
+
+	v.SetExpiring("b", clock.Now().Add(time.Second))
+	clock.Advance(time.Second)
+	_, err = v.Get(DontWait)
+	require.Error(t, err, "replacement value isn't pinned")
+
+	v.SetExpiring("c", clock.Now().Add(time.Second))
+	_, unpinC, err := v.Pin()
+	require.NoError(t, err)
+	unpin()
+	clock.Advance(time.Second)
+	r, err := v.Get(DontWait)
+	require.NoError(t, err, "unpinning a replaced value shouldn't affect the current one")
+	require.Equal(t, "c", r)
+	unpinC()
+}
+
+func TestPinKeepsExpiration(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMap[string, int](WithClock(clock))
+	m.SetExpiring("a", 1, clock.Now().Add(time.Minute))
+	v := m.(*emap[string, int]).getValue("a")
+	_, unpin, err := v.Pin()
+	require.NoError(t, err)
+	defer unpin()
+
+	expiration, ok := v.Expiration()
+	require.True(t, ok)
+	require.Equal(t, clock.Now().Add(time.Minute), expiration, "pinned value should keep its expiration")
+	require.Equal(t, []int{0, 1}, m.ExpiryHistogram([]time.Duration{time.Second}))
+
+	var buf bytes.Buffer
+	require.NoError(t, m.Export(&buf))
+	imported := NewMap[string, int](WithClock(clock))
+	require.NoError(t, imported.Import(&buf))
+	r, err := imported.Get(DontWait, "a")
+	require.NoError(t, err, "pinned entry should be exported with its expiration")
+	require.Equal(t, 1, r)
+}
@@ -0,0 +1,32 @@
+package eventual
+
+// Provenance describes where a value came from, so that when several producers can set the same
+// Value it's possible to tell which of them produced what's being served.
+type Provenance struct {
+	// Source identifies the producer, e.g. "config-file" or "control-plane".
+	Source string
+
+	// TraceID, if set, ties the value to the trace of the operation that produced it.
+	TraceID string
+}
+
+func (v *value[V]) SetWithProvenance(i V, p Provenance) {
+	v.m.Lock()
+	if err := v.writable(); err != nil {
+		v.m.Unlock()
+		v.reportMisuse(err)
+		return
+	}
+	t := v.clock.Now().Add(tenYears)
+	v.doSet(i, t, t, p)
+	v.m.Unlock()
+}
+
+func (v *value[V]) Provenance() Provenance {
+	v.m.Lock()
+	defer v.m.Unlock()
+	if !v.snapshot().valid(v.clock.Now()) {
+		return Provenance{}
+	}
+	return v.provenance
+}
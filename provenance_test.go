@@ -0,0 +1,65 @@
+package eventual
+
+import (
+	"context"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenance(t *testing.T) {
+	p := Provenance{Source: "control-plane", TraceID: "abc123"}
+	v := NewValue[string]()
+	require.Zero(t, v.Provenance())
+
+	v.SetWithProvenance("a", p)
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "a", r)
+	require.Equal(t, p, v.Provenance())
+
+	v.Set("b")
+	require.Zero(t, v.Provenance(), "a plain Set should clear the provenance")
+
+	v.SetWithProvenance("c", p)
+	v.Reset()
+	require.Zero(t, v.Provenance())
+}
+
+func TestProvenanceExpires(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	v.SetWithProvenance("a", Provenance{Source: "file"})
+	v.SetExpiring("a", clock.Now().Add(time.Second))
+	require.Zero(t, v.Provenance())
+
+	v.SetWithProvenance("b", Provenance{Source: "file"})
+	clock.Advance(tenYears)
+	require.Zero(t, v.Provenance(), "expired values shouldn't report a provenance")
+}
+
+func TestMapProvenance(t *testing.T) {
+	p := Provenance{Source: "control-plane"}
+	m := NewMap[string, string]()
+	require.Zero(t, m.Provenance("a"))
+	require.Equal(t, 0, m.Stats().Keys, "Provenance shouldn't track unknown keys")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	next, stop := iter.Pull2(m.Events(ctx))
+	defer stop()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.SetWithProvenance("a", "A", p)
+	}()
+	key, u, ok := next()
+	require.True(t, ok)
+	require.Equal(t, "a", key)
+	require.Equal(t, UpdateSet, u.Kind)
+	require.Equal(t, "A", u.Value)
+	require.Equal(t, p, u.Provenance)
+	require.Equal(t, p, m.Provenance("a"))
+}
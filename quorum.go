@@ -0,0 +1,62 @@
+package eventual
+
+// WithQuorum makes a Value wait for n distinct producers before it becomes set. Producers are told
+// apart by the Source of the Provenance they Set with (see Value.SetWithProvenance); Sets without a
+// provenance count as one anonymous producer. Once n producers have Set, the Value is set to their
+// latest values merged with reduce, in the order in which the producers first Set, and it's
+// recomputed on every subsequent Set. reduce must accept the Value's (or Map's) value type. Reset
+// and SetError start over from no producers.
+func WithQuorum[V comparable](n int, reduce func(acc V, value V) V) Option {
+	return func(o *options) {
+		o.quorum = n
+		o.reducer = reduce
+	}
+}
+
+// quorum tracks the latest value Set by each producer of a Value configured with WithQuorum.
+type quorum[V comparable] struct {
+	n       int
+	reduce  func(V, V) V
+	sources []string
+	values  map[string]V
+}
+
+func newQuorum[V comparable](n int, reduce func(V, V) V) *quorum[V] {
+	return &quorum[V]{n: n, reduce: reduce, values: make(map[string]V)}
+}
+
+// add records that source Set i, returning the merged value if there's a quorum.
+func (q *quorum[V]) add(source string, i V) (V, bool) {
+	if _, found := q.values[source]; !found {
+		q.sources = append(q.sources, source)
+	}
+	q.values[source] = i
+	if len(q.sources) < q.n {
+		var zero V
+		return zero, false
+	}
+	result := q.values[q.sources[0]]
+	for _, source := range q.sources[1:] {
+		result = q.reduce(result, q.values[source])
+	}
+	return result, true
+}
+
+// clear forgets all producers.
+func (q *quorum[V]) clear() {
+	q.sources = nil
+	q.values = make(map[string]V)
+}
+
+// clone returns a copy of q, or nil if q is nil.
+func (q *quorum[V]) clone() *quorum[V] {
+	if q == nil {
+		return nil
+	}
+	result := newQuorum(q.n, q.reduce)
+	result.sources = append(result.sources, q.sources...)
+	for source, i := range q.values {
+		result.values[source] = i
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+package eventual
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuorum(t *testing.T) {
+	sum := func(acc int, i int) int { return acc + i }
+	v := NewValue[int](WithQuorum(3, sum))
+
+	v.SetWithProvenance(1, Provenance{Source: "a"})
+	v.SetWithProvenance(2, Provenance{Source: "b"})
+	v.SetWithProvenance(3, Provenance{Source: "b"})
+	_, err := v.Get(DontWait)
+	require.True(t, errors.Is(err, ErrNotSet), "only two distinct producers have Set")
+
+	v.Set(10)
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, 14, r, "latest values of each producer should be merged")
+
+	clone := v.Clone()
+	v.SetWithProvenance(5, Provenance{Source: "a"})
+	r, _ = v.Get(DontWait)
+	require.Equal(t, 18, r, "value should be recomputed on every Set after the quorum")
+	clone.SetWithProvenance(6, Provenance{Source: "c"})
+	r, _ = clone.Get(DontWait)
+	require.Equal(t, 20, r, "clone should keep the producers independently")
+
+	v.Reset()
+	v.SetWithProvenance(1, Provenance{Source: "a"})
+	_, err = v.Get(DontWait)
+	require.True(t, errors.Is(err, ErrNotSet), "Reset should start over")
+}
@@ -0,0 +1,72 @@
+package eventual
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Readiness aggregates the readiness of many named components, typically eventual Values that are
+// populated during startup, into a single report. It implements http.Handler so that it can serve
+// as a Kubernetes readiness probe.
+type Readiness struct {
+	mx     sync.RWMutex
+	names  []string
+	checks map[string]func() bool
+}
+
+// NewReadiness creates an empty Readiness, which is ready until something unready is added.
+func NewReadiness() *Readiness {
+	return &Readiness{checks: make(map[string]func() bool)}
+}
+
+// Add registers a named readiness check. Adding a name that's already registered replaces its
+// check.
+func (r *Readiness) Add(name string, ready func() bool) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if _, found := r.checks[name]; !found {
+		r.names = append(r.names, name)
+	}
+	r.checks[name] = ready
+}
+
+// AddValue registers v with r under the given name. v counts as ready while it's set and hasn't
+// expired. Defaults don't count as being set.
+func AddValue[V comparable](r *Readiness, name string, v Value[V]) {
+	r.Add(name, func() bool {
+		return v.IsSet()
+	})
+}
+
+// Ready reports whether every registered check is ready.
+func (r *Readiness) Ready() bool {
+	return len(r.Unready()) == 0
+}
+
+// Unready returns the names of the checks that aren't ready, in the order they were added.
+func (r *Readiness) Unready() []string {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	var unready []string
+	for _, name := range r.names {
+		if !r.checks[name]() {
+			unready = append(unready, name)
+		}
+	}
+	return unready
+}
+
+// ServeHTTP responds with 200 OK if everything is ready, and otherwise with 503 Service Unavailable
+// listing what isn't ready.
+func (r *Readiness) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	resp.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	unready := r.Unready()
+	if len(unready) == 0 {
+		fmt.Fprintln(resp, "ready")
+		return
+	}
+	resp.WriteHeader(http.StatusServiceUnavailable)
+	fmt.Fprintf(resp, "not ready: %v\n", strings.Join(unready, ", "))
+}
@@ -0,0 +1,38 @@
+package eventual
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness(t *testing.T) {
+	r := NewReadiness()
+	require.True(t, r.Ready())
+
+	config := NewValue[string]()
+	certs := WithDefault("fallback")
+	AddValue(r, "config", config)
+	AddValue(r, "certs", certs)
+	r.Add("db", func() bool { return true })
+
+	require.False(t, r.Ready())
+	require.Equal(t, []string{"config", "certs"}, r.Unready(), "default shouldn't count as ready")
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, "not ready: config, certs\n", rec.Body.String())
+
+	config.Set("loaded")
+	certs.Set("loaded")
+	require.True(t, r.Ready())
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	config.Reset()
+	require.Equal(t, []string{"config"}, r.Unready())
+}
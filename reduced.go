@@ -0,0 +1,51 @@
+package eventual
+
+import (
+	"context"
+)
+
+// Reduced is a Value that folds everything Set to it into an aggregate (see NewReduced), for
+// example to combine partial results from many workers.
+type Reduced[V any, A comparable] interface {
+	// Set folds value into the aggregate.
+	Set(value V)
+
+	// Get waits for the first Set and returns the current aggregate. See Value.Get.
+	Get(ctx context.Context) (A, error)
+
+	// Peek returns the current aggregate without waiting, and whether there is one yet.
+	Peek() (A, bool)
+
+	// Reset discards the aggregate, so that the next Set starts over from the zero value.
+	Reset()
+}
+
+type reduced[V any, A comparable] struct {
+	v      *value[A]
+	reduce func(acc A, v V) A
+}
+
+// NewReduced creates a Reduced that folds each Set into the aggregate with reduce, starting from
+// the zero value of A. reduce is called while holding the Reduced's lock, so Sets from concurrent
+// producers are folded in one at a time, and reduce must not access the Reduced.
+func NewReduced[V any, A comparable](reduce func(acc A, v V) A, opts ...Option) Reduced[V, A] {
+	return &reduced[V, A]{v: newValue[A](opts), reduce: reduce}
+}
+
+func (r *reduced[V, A]) Set(value V) {
+	r.v.Update(func(acc A, _ bool) A {
+		return r.reduce(acc, value)
+	})
+}
+
+func (r *reduced[V, A]) Get(ctx context.Context) (A, error) {
+	return r.v.Get(ctx)
+}
+
+func (r *reduced[V, A]) Peek() (A, bool) {
+	return r.v.Peek()
+}
+
+func (r *reduced[V, A]) Reset() {
+	r.v.Reset()
+}
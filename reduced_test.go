@@ -0,0 +1,47 @@
+package eventual
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReduced(t *testing.T) {
+	r := NewReduced(func(acc int, partial []int) int {
+		for _, i := range partial {
+			acc += i
+		}
+		return acc
+	})
+	_, ok := r.Peek()
+	require.False(t, ok)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		r.Set([]int{1, 2})
+	}()
+	sum, err := r.Get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, sum)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Set([]int{1})
+		}()
+	}
+	wg.Wait()
+	sum, ok = r.Peek()
+	require.True(t, ok)
+	require.Equal(t, 103, sum, "concurrent Sets should all be folded in")
+
+	r.Reset()
+	r.Set([]int{5})
+	sum, _ = r.Peek()
+	require.Equal(t, 5, sum)
+}
@@ -0,0 +1,82 @@
+package eventual
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Refreshing is a Value that keeps itself up to date by periodically calling a getter in the
+// background (see NewRefreshing).
+type Refreshing[V comparable] interface {
+	Value[V]
+
+	// Stop cancels the next scheduled refresh and any refresh that's currently in flight. The
+	// current value is kept, but it's no longer refreshed.
+	Stop()
+}
+
+type refreshing[V comparable] struct {
+	*value[V]
+	getter   func(context.Context) (V, error)
+	interval time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
+	timerMx  sync.Mutex
+	timer    Timer
+	stopped  bool
+}
+
+// NewRefreshing creates a Value that's populated by calling getter in a background goroutine right
+// away, and again every interval after the previous call finished. If getter fails, the Value keeps
+// its current value and the error is available from LastError. getter's context is cancelled by
+// Stop.
+func NewRefreshing[V comparable](interval time.Duration, getter func(ctx context.Context) (V, error), opts ...Option) Refreshing[V] {
+	r := &refreshing[V]{
+		value:    newValue[V](opts),
+		getter:   getter,
+		interval: interval,
+	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	go r.refresh()
+	return r
+}
+
+func (r *refreshing[V]) refresh() {
+	r.m.Lock()
+	r.beginLoad()
+	r.m.Unlock()
+
+	i, err := r.getter(r.ctx)
+
+	r.m.Lock()
+	r.endLoad()
+	if r.ctx.Err() == nil {
+		r.recordError(err)
+		if err == nil && r.writable() == nil {
+			r.doSetExpiring(i, r.clock.Now().Add(tenYears))
+		}
+	}
+	r.m.Unlock()
+
+	r.timerMx.Lock()
+	defer r.timerMx.Unlock()
+	if !r.stopped {
+		r.timer = r.clock.AfterFunc(r.interval, r.refresh)
+	}
+}
+
+func (r *refreshing[V]) Stop() {
+	r.timerMx.Lock()
+	r.stopped = true
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.timerMx.Unlock()
+	r.cancel()
+}
+
+func (r *refreshing[V]) Close() {
+	r.Stop()
+	r.value.Close()
+}
@@ -0,0 +1,65 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshing(t *testing.T) {
+	clock := newFakeClock()
+	var calls int32
+	fail := errors.New("fail")
+	v := NewRefreshing[int](time.Minute, func(ctx context.Context) (int, error) {
+		n := atomic.AddInt32(&calls, 1)
+		if n == 2 {
+			return 0, fail
+		}
+		return int(n), nil
+	}, WithClock(clock))
+	defer v.Stop()
+
+	r, err := v.Get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, r)
+
+	require.Eventually(t, func() bool { return clock.pending() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool {
+		err, _ := v.LastError()
+		return err == fail
+	}, time.Second, time.Millisecond)
+	r, _ = v.Get(DontWait)
+	require.Equal(t, 1, r, "failed refresh should keep the current value")
+
+	require.Eventually(t, func() bool { return clock.pending() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool {
+		r, _ := v.Get(DontWait)
+		return r == 3
+	}, time.Second, time.Millisecond)
+}
+
+func TestRefreshingStop(t *testing.T) {
+	clock := newFakeClock()
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	v := NewRefreshing[int](time.Minute, func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return 1, nil
+	}, WithClock(clock))
+
+	<-started
+	v.Stop()
+	<-cancelled
+	time.Sleep(10 * time.Millisecond)
+	require.Zero(t, clock.pending(), "no refresh should be scheduled after Stop")
+	_, err := v.Get(DontWait)
+	require.Error(t, err, "result of cancelled refresh shouldn't be stored")
+}
@@ -0,0 +1,76 @@
+package eventual
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+// NewSecret creates a new value for sensitive payloads such as tokens and keys. A secret value
+// never includes its payload in String() or other fmt output, and Reset makes a best-effort attempt
+// to zero the memory backing byte payloads (e.g. *[]byte or *[32]byte) before releasing them. It's
+// the same as NewValue with WithSensitive.
+func NewSecret[V comparable](opts ...Option) Value[V] {
+	return newValue[V](append(opts[:len(opts):len(opts)], WithSensitive()))
+}
+
+// WithSensitive makes Values secrets (see NewSecret). Passed to NewMap, it makes every Value in the
+// Map a secret, and the updates yielded by Map.Events carry a Digest instead of the Value.
+func WithSensitive() Option {
+	return func(o *options) {
+		o.sensitive = true
+	}
+}
+
+// WithAuditHook configures a function that's called after every update to a Value, with the
+// update's kind and time and, for UpdateSet, a digest of the new payload (see Update.Digest), so
+// that changes to secrets can be audited without revealing them. For a Map, it's called for the
+// updates to any of its keys; Map.Events yields the same digests along with the keys. hook is
+// called while holding the Value's lock, so it must not block or use the Value.
+func WithAuditHook(hook func(kind UpdateKind, at time.Time, digest string)) Option {
+	return func(o *options) {
+		o.audit = hook
+	}
+}
+
+// digest returns the hex encoded SHA-256 of i's default formatting.
+func digest(i interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%v", i)))
+	return hex.EncodeToString(sum[:])
+}
+
+// emit reports u to the audit hook and the update hook, if any. Must be called while holding the
+// mutex.
+func (v *value[V]) emit(u Update[V]) {
+	if u.Kind == UpdateSet && (v.sensitive || v.audit != nil) {
+		u.Digest = digest(u.Value)
+	}
+	if v.audit != nil {
+		v.audit(u.Kind, u.Time, u.Digest)
+	}
+	if v.onUpdate != nil {
+		v.onUpdate(u)
+	}
+}
+
+// zeroBytes overwrites the bytes reachable from i with zeros if i is a byte slice or a pointer to a
+// byte slice or byte array. Anything else is left untouched.
+func zeroBytes(i interface{}) {
+	rv := reflect.ValueOf(i)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return
+	}
+	if rv.Type().Elem().Kind() != reflect.Uint8 || (rv.Kind() == reflect.Array && !rv.CanSet()) {
+		return
+	}
+	for i := 0; i < rv.Len(); i++ {
+		rv.Index(i).SetUint(0)
+	}
+}
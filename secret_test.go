@@ -0,0 +1,89 @@
+package eventual
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecret(t *testing.T) {
+	v := NewSecret[string]()
+	require.Equal(t, "<unset>", fmt.Sprint(v))
+
+	v.Set("hunter2")
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+		require.NotContains(t, fmt.Sprintf(verb, v), "hunter2", verb)
+	}
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", r)
+
+	plain := NewValue[string](WithVisiblePayloads())
+	plain.Set("visible")
+	require.Equal(t, "visible", fmt.Sprint(plain))
+}
+
+func TestSecretResetZeroesBytes(t *testing.T) {
+	key := []byte("super secret key")
+	v := NewSecret[*[]byte]()
+	v.Set(&key)
+	v.Reset()
+	require.Equal(t, make([]byte, len(key)), key)
+
+	var arr [4]byte
+	copy(arr[:], "abcd")
+	av := NewSecret[*[4]byte]()
+	av.Set(&arr)
+	av.Reset()
+	require.Equal(t, [4]byte{}, arr)
+}
+
+func TestSecretMapEvents(t *testing.T) {
+	m := NewMap[string, string](WithSensitive())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	next, stop := iter.Pull2(m.Events(ctx))
+	defer stop()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.Set("token", "hunter2")
+	}()
+	key, u, ok := next()
+	require.True(t, ok)
+	require.Equal(t, "token", key)
+	require.Equal(t, UpdateSet, u.Kind)
+	require.Empty(t, u.Value, "secret shouldn't be included in events")
+	require.Equal(t, digest("hunter2"), u.Digest)
+	require.NotContains(t, u.Digest, "hunter2")
+
+	r, err := m.Get(DontWait, "token")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", r)
+	require.NotContains(t, fmt.Sprint(m.(*emap[string, string]).getValue("token")), "hunter2")
+}
+
+func TestAuditHook(t *testing.T) {
+	type audit struct {
+		kind   UpdateKind
+		digest string
+	}
+	var audits []audit
+	v := NewSecret[string](WithAuditHook(func(kind UpdateKind, at time.Time, digest string) {
+		audits = append(audits, audit{kind, digest})
+	}))
+	v.Set("hunter2")
+	v.Set("hunter3")
+	v.Reset()
+	require.Equal(t, []audit{
+		{UpdateSet, digest("hunter2")},
+		{UpdateSet, digest("hunter3")},
+		{UpdateReset, ""},
+	}, audits)
+	require.NotEqual(t, audits[0].digest, audits[1].digest)
+	require.Len(t, audits[0].digest, 64)
+}
@@ -0,0 +1,228 @@
+package eventual
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+const defaultShards = 16
+
+// WithShards sets the number of shards a Map splits its keys across, each with its own lock.
+// Values are rounded up to the next power of two. The default is 16; use 1 to disable sharding.
+func WithShards(n int) Option {
+	return func(o *options) {
+		o.shards = n
+	}
+}
+
+// WithShardFn configures the function a Map uses to assign keys to shards. It must accept the
+// Map's key type. Only the low bits of the result are used, so the function should distribute keys
+// evenly across them. The default hashes the key.
+func WithShardFn[K comparable](shardFn func(K) uint32) Option {
+	return func(o *options) {
+		o.shardFn = shardFn
+	}
+}
+
+type shard[K comparable, V comparable] struct {
+	mx sync.Mutex
+	m  map[K]*value[V]
+	// read is a read-only copy of m published for lock-free lookups of existing keys. It may lag
+	// behind m; keys missing from it are looked up in m while holding mx.
+	read   atomic.Value
+	misses int
+}
+
+// lookup finds key in the published read index without locking.
+func (s *shard[K, V]) lookup(key K) *value[V] {
+	read, _ := s.read.Load().(map[K]*value[V])
+	return read[key]
+}
+
+// missed records a lookup that had to fall back to m. Once misses have cost about as much as
+// copying m would, m is republished as the new read index. Must be called while holding mx.
+func (s *shard[K, V]) missed() {
+	s.misses++
+	if s.misses < len(s.m) {
+		return
+	}
+	read := make(map[K]*value[V], len(s.m))
+	for key, v := range s.m {
+		read[key] = v
+	}
+	s.read.Store(read)
+	s.misses = 0
+}
+
+// shardCount rounds n up to a power of two between 1 and 2^16.
+func shardCount(n int) int {
+	if n <= 0 {
+		return defaultShards
+	}
+	if n > math.MaxUint16 {
+		n = math.MaxUint16
+	}
+	result := 1
+	for result < n {
+		result <<= 1
+	}
+	return result
+}
+
+// hashFn returns a function that hashes keys of type K. Keys whose underlying type is a string or
+// integer are hashed directly without allocating, other keys are hashed by walking them with
+// reflection.
+func hashFn[K comparable]() func(K) uint32 {
+	t := reflect.TypeOf((*K)(nil)).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		return func(key K) uint32 {
+			return hashString(*(*string)(unsafe.Pointer(&key)))
+		}
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch t.Size() {
+		case 8:
+			return func(key K) uint32 {
+				return mix64(*(*uint64)(unsafe.Pointer(&key)))
+			}
+		case 4:
+			return func(key K) uint32 {
+				return mix64(uint64(*(*uint32)(unsafe.Pointer(&key))))
+			}
+		}
+	}
+	return func(key K) uint32 {
+		h := fnv(2166136261)
+		h.value(reflect.ValueOf(&key).Elem())
+		return uint32(h)
+	}
+}
+
+// fnv is the state of a 32-bit FNV-1a hash.
+type fnv uint32
+
+func (h *fnv) byte(b byte) {
+	*h = (*h ^ fnv(b)) * 16777619
+}
+
+func (h *fnv) uint64(u uint64) {
+	for i := 0; i < 8; i++ {
+		h.byte(byte(u >> (8 * i)))
+	}
+}
+
+func (h *fnv) string(s string) {
+	for i := 0; i < len(s); i++ {
+		h.byte(s[i])
+	}
+}
+
+func (h *fnv) float(f float64) {
+	switch {
+	case f == 0:
+		// -0 == 0, so they must hash the same
+		f = 0
+	case f != f:
+		// NaN keys never equal anything, so any hash will do as long as it's stable
+		f = math.NaN()
+	}
+	h.uint64(math.Float64bits(f))
+}
+
+// value hashes v such that values that compare equal with == hash the same.
+func (h *fnv) value(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		h.string(v.String())
+	case reflect.Bool:
+		if v.Bool() {
+			h.byte(1)
+		} else {
+			h.byte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		h.uint64(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		h.uint64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		h.float(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		h.float(real(c))
+		h.float(imag(c))
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		h.uint64(uint64(v.Pointer()))
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			h.value(v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			h.value(v.Field(i))
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			h.byte(0)
+			return
+		}
+		// Interfaces holding different dynamic types are never equal
+		h.string(v.Elem().Type().String())
+		h.value(v.Elem())
+	}
+}
+
+// hashString is 32-bit FNV-1a.
+func hashString(s string) uint32 {
+	h := fnv(2166136261)
+	h.string(s)
+	return uint32(h)
+}
+
+// mix64 is the murmur3 finalizer, folded to 32 bits.
+func mix64(k uint64) uint32 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return uint32(k)
+}
+
+// WithSkewHandler configures a function that a Map calls with its shard occupancy (see
+// Map.ShardOccupancy) when the fullest shard holds more than threshold times the average number of
+// keys per shard, for example because all keys share a prefix that the shard function doesn't
+// distinguish. The distribution is checked each time the Map has started tracking as many new keys
+// as it has shards, and onSkew is only called again once the skew has gone away in between.
+func WithSkewHandler(threshold float64, onSkew func(occupancy []int)) Option {
+	return func(o *options) {
+		o.skewThreshold = threshold
+		o.onSkew = onSkew
+	}
+}
+
+// created records that the Map started tracking a new key, checking for skew if it's time to.
+// Must be called without holding any shard's lock.
+func (m *emap[K, V]) created() {
+	if m.onSkew == nil || m.creations.Add(1)%uint64(len(m.shards)) != 0 {
+		return
+	}
+	occupancy := m.ShardOccupancy()
+	total, fullest := 0, 0
+	for _, n := range occupancy {
+		total += n
+		if n > fullest {
+			fullest = n
+		}
+	}
+	average := float64(total) / float64(len(occupancy))
+	if float64(fullest) <= m.skewThreshold*average {
+		m.skewed.Store(false)
+		return
+	}
+	if !m.skewed.Swap(true) {
+		m.onSkew(occupancy)
+	}
+}
@@ -0,0 +1,65 @@
+package eventual
+
+import (
+	"fmt"
+)
+
+// SingleflightGroup is the subset of golang.org/x/sync/singleflight.Group's API that DoInto needs.
+// A *singleflight.Group satisfies it, without this package having to depend on x/sync.
+type SingleflightGroup interface {
+	Do(key string, fn func() (interface{}, error)) (v interface{}, err error, shared bool)
+}
+
+// DoInto populates value using fn, deduplicated through group under key. Callers that go through
+// the group directly share the execution with callers of DoInto, so codebases that are migrating
+// between the two never run a loader twice. Whichever caller's function ran, the shared result is
+// stored in value, unless value was set in the meantime. If value is already set, it's returned
+// without calling fn. Defaults and fallbacks don't count as being set. If fn returns an error, nothing is stored and the error is returned.
+func DoInto[V comparable](group SingleflightGroup, key string, value Value[V], fn func() (V, error)) (V, error) {
+	if result, ok := value.Peek(); ok {
+		return result, nil
+	}
+
+	result, err := doShared(group, key, fn)
+	if err != nil {
+		return result, err
+	}
+	value.SetIfAbsent(result)
+	return result, nil
+}
+
+// DoIntoMap is like DoInto but populates key in m. The singleflight key is key formatted with
+// fmt.Sprint.
+func DoIntoMap[K comparable, V comparable](group SingleflightGroup, m Map[K, V], key K, fn func() (V, error)) (V, error) {
+	if result, ok := m.Peek(key); ok {
+		return result, nil
+	}
+
+	result, err := doShared(group, fmt.Sprint(key), fn)
+	if err != nil {
+		return result, err
+	}
+	m.SetIfAbsent(key, result)
+	return result, nil
+}
+
+// doShared calls fn through group under key and returns the shared result, which may have come
+// from a different caller's function.
+func doShared[V comparable](group SingleflightGroup, key string, fn func() (V, error)) (V, error) {
+	var zero V
+	result, err, _ := group.Do(key, func() (interface{}, error) {
+		result, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+	if err != nil {
+		return zero, err
+	}
+	typed, ok := result.(V)
+	if !ok {
+		return zero, fmt.Errorf("eventual: singleflight call for %q returned a %T, not a %T", key, result, zero)
+	}
+	return typed, nil
+}
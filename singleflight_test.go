@@ -0,0 +1,194 @@
+package eventual
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testGroup is a minimal stand-in for singleflight.Group.
+type testGroup struct {
+	mx    sync.Mutex
+	calls map[string]*testCall
+}
+
+type testCall struct {
+	wg  sync.WaitGroup
+	v   interface{}
+	err error
+}
+
+func (g *testGroup) Do(key string, fn func() (interface{}, error)) (interface{}, error, bool) {
+	g.mx.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*testCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mx.Unlock()
+		c.wg.Wait()
+		return c.v, c.err, true
+	}
+	c := &testCall{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mx.Unlock()
+
+	c.v, c.err = fn()
+	c.wg.Done()
+
+	g.mx.Lock()
+	delete(g.calls, key)
+	g.mx.Unlock()
+	return c.v, c.err, false
+}
+
+func TestDoInto(t *testing.T) {
+	var (
+		group testGroup
+		calls int32
+		wg    sync.WaitGroup
+		v     = NewValue[string]()
+	)
+	fn := func() (string, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		return "loaded", nil
+	}
+
+	results := make(chan interface{}, 6)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := DoInto(&group, "k", v, fn)
+			if err != nil {
+				results <- err
+				return
+			}
+			results <- r
+		}()
+	}
+	// A caller still using the group directly shares the same execution
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		time.Sleep(5 * time.Millisecond)
+		r, _, _ := group.Do("k", func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return "direct", nil
+		})
+		results <- r
+	}()
+	wg.Wait()
+	close(results)
+	for r := range results {
+		require.Equal(t, "loaded", r)
+	}
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+	r, err := v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "loaded", r)
+
+	r, err = DoInto(&group, "k", v, fn)
+	require.NoError(t, err)
+	require.Equal(t, "loaded", r)
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls), "set value shouldn't be reloaded")
+}
+
+func TestDoIntoMap(t *testing.T) {
+	var group testGroup
+	m := NewMap[int, string]()
+
+	_, err := DoIntoMap(&group, m, 1, func() (string, error) {
+		return "", errors.New("failed")
+	})
+	require.Error(t, err)
+	_, err = m.Get(DontWait, 1)
+	require.Error(t, err, "failed load shouldn't store anything")
+
+	r, err := DoIntoMap(&group, m, 1, func() (string, error) {
+		return "one", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "one", r)
+	r, err = m.Get(DontWait, 1)
+	require.NoError(t, err)
+	require.Equal(t, "one", r)
+}
+
+func TestDoIntoWithDefault(t *testing.T) {
+	var group testGroup
+	v := WithDefault("default")
+	r, err := DoInto(&group, "v", v, func() (string, error) { return "loaded", nil })
+	require.NoError(t, err)
+	require.Equal(t, "loaded", r, "default shouldn't count as set")
+	r, ok := v.Peek()
+	require.True(t, ok)
+	require.Equal(t, "loaded", r)
+
+	m := NewMapWithDefaults(map[int]string{1: "default"})
+	r, err = DoIntoMap(&group, m, 1, func() (string, error) { return "loaded", nil })
+	require.NoError(t, err)
+	require.Equal(t, "loaded", r, "default shouldn't count as set")
+	r, ok = m.Peek(1)
+	require.True(t, ok)
+	require.Equal(t, "loaded", r)
+}
+
+func TestDoIntoSharedWithDirectCaller(t *testing.T) {
+	var group testGroup
+	v := NewValue[string]()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go group.Do("k", func() (interface{}, error) {
+		close(started)
+		<-release
+		return "direct", nil
+	})
+	<-started
+
+	done := make(chan error)
+	go func() {
+		_, err := DoInto(&group, "k", v, func() (string, error) {
+			return "", errors.New("shouldn't be called")
+		})
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	require.NoError(t, <-done)
+	r, err := v.Get(DontWait)
+	require.NoError(t, err, "result of the direct caller's function should be stored")
+	require.Equal(t, "direct", r)
+}
+
+func TestDoIntoWrongType(t *testing.T) {
+	var group testGroup
+	for _, foreign := range []interface{}{nil, 42} {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		go group.Do("0", func() (interface{}, error) {
+			close(started)
+			<-release
+			return foreign, nil
+		})
+		<-started
+
+		m := NewMap[int, string]()
+		done := make(chan error)
+		go func() {
+			_, err := DoIntoMap(&group, m, 0, func() (string, error) { return "mine", nil })
+			done <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		require.Error(t, <-done, "foreign result %v should be an error, not a panic", foreign)
+		_, ok := m.Peek(0)
+		require.False(t, ok)
+	}
+}
@@ -0,0 +1,124 @@
+package eventual
+
+import (
+	"fmt"
+	"time"
+)
+
+func (v *value[V]) SetExpiringSoft(i V, soft time.Time, hard time.Time) {
+	if soft.After(hard) {
+		soft = hard
+	}
+	v.m.Lock()
+	if err := v.writable(); err != nil {
+		v.m.Unlock()
+		v.reportMisuse(err)
+		return
+	}
+	v.doSet(i, soft, hard, Provenance{})
+	v.m.Unlock()
+}
+
+func (s *snapshot[V]) stale(now time.Time) bool {
+	return !s.softExpiration.IsZero() && !s.softExpiration.After(now)
+}
+
+// Freshness describes where a value returned by GetOrSetWithFreshness came from.
+type Freshness int
+
+const (
+	// FreshnessHit means that a fresh value was already stored.
+	FreshnessHit Freshness = iota
+	// FreshnessLoaded means that the value was just loaded by the getter.
+	FreshnessLoaded
+	// FreshnessStale means that a stale value was served while being refreshed in the background.
+	FreshnessStale
+)
+
+func (f Freshness) String() string {
+	switch f {
+	case FreshnessHit:
+		return "hit"
+	case FreshnessLoaded:
+		return "loaded"
+	case FreshnessStale:
+		return "stale"
+	default:
+		return fmt.Sprintf("Freshness(%d)", int(f))
+	}
+}
+
+func (v *value[V]) GetOrSetSoftExpiring(soft time.Time, hard time.Time, getter func() (V, error)) (V, error) {
+	result, _, err := v.GetOrSetWithFreshness(soft, hard, getter)
+	return result, err
+}
+
+func (v *value[V]) GetOrSetWithFreshness(soft time.Time, hard time.Time, getter func() (V, error)) (V, Freshness, error) {
+	if soft.After(hard) {
+		soft = hard
+	}
+	now := v.clock.Now()
+	if s := v.snapshot(); s.valid(now) {
+		if s.stale(now) {
+			v.refreshInBackground(soft, hard, getter)
+			return s.v, FreshnessStale, nil
+		}
+		return s.v, FreshnessHit, nil
+	}
+
+	v.m.Lock()
+	if s := v.snapshot(); s.valid(v.clock.Now()) {
+		// Set while we were waiting for the lock
+		v.m.Unlock()
+		return s.v, FreshnessHit, nil
+	}
+	if v.closed.Load() {
+		v.m.Unlock()
+		return v.zeroValue, FreshnessLoaded, ErrClosed
+	}
+	i, err := v.load(getter, func(i V, err error) (V, error) {
+		if err != nil {
+			return v.zeroValue, err
+		}
+		if v.writable() == nil {
+			v.doSet(i, soft, hard, Provenance{})
+		}
+		return i, nil
+	})
+	return i, FreshnessLoaded, err
+}
+
+// refreshInBackground runs getter in a new goroutine and stores its result, unless a refresh is
+// already running. If getter panics, the panic is recorded as the refresh's error.
+func (v *value[V]) refreshInBackground(soft time.Time, hard time.Time, getter func() (V, error)) {
+	v.m.Lock()
+	if v.refreshing {
+		v.m.Unlock()
+		return
+	}
+	v.refreshing = true
+	v.beginLoad()
+	v.m.Unlock()
+
+	go func() {
+		var i V
+		err := errGetterPanicked
+		defer func() {
+			// Nobody is waiting for a background refresh, so a panicking getter is recorded like
+			// any other failed refresh, rather than crashing the program or leaving refreshing set
+			// forever.
+			if p := recover(); p != nil {
+				err = fmt.Errorf("%w: %v", errGetterPanicked, p)
+			}
+			v.m.Lock()
+			defer v.m.Unlock()
+			v.refreshing = false
+			v.endLoad()
+			v.recordError(err)
+			if err == nil && v.writable() == nil {
+				v.doSet(i, soft, hard, Provenance{})
+			}
+		}()
+		i, err = getter()
+	}()
+}
@@ -0,0 +1,123 @@
+package eventual
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftExpiration(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[int](WithClock(clock))
+
+	var loads int32
+	refreshed := make(chan struct{}, 10)
+	getter := func() (int, error) {
+		n := atomic.AddInt32(&loads, 1)
+		refreshed <- struct{}{}
+		return int(n), nil
+	}
+	get := func() int {
+		r, err := v.GetOrSetSoftExpiring(clock.Now().Add(time.Minute), clock.Now().Add(time.Hour), getter)
+		require.NoError(t, err)
+		return r
+	}
+
+	require.Equal(t, 1, get(), "unset value should be loaded synchronously")
+	<-refreshed
+	require.Equal(t, 1, get(), "fresh value should be served without loading")
+
+	clock.Advance(2 * time.Minute)
+	require.Equal(t, 1, get(), "stale value should be served immediately")
+	<-refreshed
+	require.Eventually(t, func() bool { return get() == 2 }, time.Second, time.Millisecond, "stale value should be refreshed in the background")
+
+	clock.Advance(2 * time.Hour)
+	require.Equal(t, 3, get(), "hard expired value should be loaded synchronously")
+	<-refreshed
+	require.EqualValues(t, 3, atomic.LoadInt32(&loads))
+}
+
+func TestSoftExpirationRefreshFailure(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	v.SetExpiringSoft("stale", clock.Now(), clock.Now().Add(time.Hour))
+
+	done := make(chan struct{})
+	failure := errors.New("failed")
+	r, err := v.GetOrSetSoftExpiring(clock.Now().Add(time.Minute), clock.Now().Add(time.Hour), func() (string, error) {
+		defer close(done)
+		return "", failure
+	})
+	require.NoError(t, err)
+	require.Equal(t, "stale", r)
+	<-done
+	require.Eventually(t, func() bool {
+		err, _ := v.LastError()
+		return err == failure
+	}, time.Second, time.Millisecond)
+
+	r, err = v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "stale", r, "failed refresh should keep the stale value")
+}
+
+func TestSoftExpirationRefreshPanic(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	v.SetExpiringSoft("stale", clock.Now(), clock.Now().Add(time.Hour))
+
+	r, err := v.GetOrSetSoftExpiring(clock.Now().Add(time.Minute), clock.Now().Add(time.Hour), func() (string, error) {
+		panic("boom")
+	})
+	require.NoError(t, err)
+	require.Equal(t, "stale", r)
+	require.Eventually(t, func() bool {
+		err, _ := v.LastError()
+		return errors.Is(err, errGetterPanicked)
+	}, time.Second, time.Millisecond, "panic should be recorded as the refresh's error")
+
+	done := make(chan struct{})
+	r, err = v.GetOrSetSoftExpiring(clock.Now().Add(time.Minute), clock.Now().Add(time.Hour), func() (string, error) {
+		defer close(done)
+		return "fresh", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "stale", r)
+	<-done
+	require.Eventually(t, func() bool {
+		r, _ := v.Get(DontWait)
+		return r == "fresh"
+	}, time.Second, time.Millisecond, "value should be refreshed again after a panic")
+}
+
+func TestGetOrSetWithFreshness(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMap[string, string](WithClock(clock))
+	done := make(chan struct{}, 1)
+	get := func() (string, Freshness) {
+		r, freshness, err := m.GetOrSetWithFreshness("k", clock.Now().Add(time.Minute), clock.Now().Add(time.Hour), func() (string, error) {
+			done <- struct{}{}
+			return "v", nil
+		})
+		require.NoError(t, err)
+		return r, freshness
+	}
+
+	r, freshness := get()
+	<-done
+	require.Equal(t, "v", r)
+	require.Equal(t, FreshnessLoaded, freshness)
+
+	_, freshness = get()
+	require.Equal(t, FreshnessHit, freshness)
+
+	clock.Advance(2 * time.Minute)
+	_, freshness = get()
+	<-done
+	require.Equal(t, FreshnessStale, freshness)
+	require.Equal(t, "stale", FreshnessStale.String())
+}
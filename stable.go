@@ -0,0 +1,41 @@
+package eventual
+
+import (
+	"context"
+	"time"
+)
+
+func (v *value[V]) GetStable(ctx context.Context, stableFor time.Duration) (V, error) {
+	start := v.clock.Now()
+	for {
+		if _, err := v.Get(ctx); err != nil {
+			return v.zeroValue, err
+		}
+		// Subscribe before looking at the value, so that we can't miss it changing
+		changed := v.NotifyExpiry()
+		s := v.snapshot()
+		now := v.clock.Now()
+		if !s.valid(now) {
+			if ctx.Err() != nil {
+				// Get returned a default or fallback, which never becomes stable
+				return v.zeroValue, unavailable(s != nil, ctx.Err())
+			}
+			continue
+		}
+		remaining := s.since.Add(stableFor).Sub(now)
+		if remaining <= 0 {
+			return s.v, nil
+		}
+
+		elapsed := make(chan struct{})
+		timer := v.clock.AfterFunc(remaining, func() { close(elapsed) })
+		select {
+		case <-elapsed:
+		case <-changed:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			return v.contextDone(ctx, start)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStable(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+
+	type result struct {
+		v   string
+		err error
+	}
+	results := make(chan result)
+	go func() {
+		r, err := v.GetStable(context.Background(), time.Second)
+		results <- result{r, err}
+	}()
+	noResult := func(msg string) {
+		t.Helper()
+		select {
+		case r := <-results:
+			t.Fatalf("%v, got %v", msg, r)
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	awaitTimer := func() {
+		t.Helper()
+		require.Eventually(t, func() bool { return clock.pending() > 0 }, time.Second, time.Millisecond)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	v.Set("a")
+	awaitTimer()
+	clock.Advance(500 * time.Millisecond)
+	v.Set("b")
+	clock.Advance(500 * time.Millisecond)
+	noResult("value was replaced before it was stable")
+
+	v.Set("b")
+	awaitTimer()
+	clock.Advance(500 * time.Millisecond)
+	require.Equal(t, result{"b", nil}, <-results)
+
+	r, err := v.GetStable(DontWait, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "b", r, "value that's already stable should be returned right away")
+}
+
+func TestGetStableReset(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[string](WithClock(clock))
+	v.Set("a")
+
+	_, err := v.GetStable(DontWait, time.Second)
+	require.Error(t, err, "value isn't stable yet")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error)
+	go func() {
+		_, err := v.GetStable(ctx, time.Second)
+		errs <- err
+	}()
+	require.Eventually(t, func() bool { return clock.pending() > 0 }, time.Second, time.Millisecond)
+	v.Reset()
+	clock.Advance(time.Second)
+	select {
+	case err := <-errs:
+		t.Fatalf("reset value shouldn't be returned, got %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	cancel()
+	require.True(t, errors.Is(<-errs, context.Canceled))
+}
+
+func TestGetStableWithDefault(t *testing.T) {
+	v := WithDefault("default")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	errs := make(chan error)
+	go func() {
+		_, err := v.GetStable(ctx, time.Second)
+		errs <- err
+	}()
+	select {
+	case err := <-errs:
+		require.True(t, errors.Is(err, context.Canceled))
+	case <-time.After(time.Second):
+		t.Fatal("GetStable shouldn't spin on the default")
+	}
+}
@@ -0,0 +1,49 @@
+package eventual
+
+import (
+	"github.com/getlantern/eventual/v3/internal/testhooks"
+)
+
+func init() {
+	testhooks.Expire = func(v interface{}) bool {
+		e, ok := v.(interface{ forceExpire() bool })
+		if ok {
+			e.forceExpire()
+		}
+		return ok
+	}
+	testhooks.ExpireKey = func(m interface{}, key interface{}) bool {
+		e, ok := m.(interface{ forceExpireKey(key interface{}) })
+		if ok {
+			e.forceExpireKey(key)
+		}
+		return ok
+	}
+}
+
+// forceExpire makes the current value expire now, reporting whether there was one.
+func (v *value[V]) forceExpire() bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+	s := v.snapshot()
+	now := v.clock.Now()
+	if !s.valid(now) {
+		return false
+	}
+	v.expiration = now
+	v.softExpiration = now
+	v.published.Store(&snapshot[V]{v: s.v, expiration: now, softExpiration: now, since: s.since})
+	v.endExpiry()
+	v.scheduleLapse()
+	return true
+}
+
+func (m *emap[K, V]) forceExpireKey(key interface{}) {
+	k, ok := key.(K)
+	if !ok {
+		return
+	}
+	if v := m.existingValue(k); v != nil {
+		v.forceExpire()
+	}
+}
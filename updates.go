@@ -0,0 +1,355 @@
+package eventual
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// UpdateKind identifies what happened in an Update.
+type UpdateKind int
+
+const (
+	// UpdateSet means that a value was set.
+	UpdateSet UpdateKind = iota
+	// UpdateReset means that a value was cleared by Reset.
+	UpdateReset
+	// UpdateError means that a value failed with an error (see Value.SetError).
+	UpdateError
+	// UpdateExpire means that a value expired. It's reported when the value's expiration passes,
+	// independently of whether anyone tried to Get it.
+	UpdateExpire
+)
+
+func (k UpdateKind) String() string {
+	switch k {
+	case UpdateSet:
+		return "set"
+	case UpdateReset:
+		return "reset"
+	case UpdateError:
+		return "error"
+	case UpdateExpire:
+		return "expire"
+	default:
+		return fmt.Sprintf("UpdateKind(%d)", int(k))
+	}
+}
+
+// Update describes a change to a value.
+type Update[V comparable] struct {
+	// Seq is the update's sequence number in Map.Events. Sequence numbers increase with every
+	// update to any of the Map's keys, so consumers can order updates to different keys. Because
+	// updates are coalesced, consumers see gaps in the sequence; an update's Old value bridges them.
+	Seq uint64
+	// Time is when the update happened, according to the configured Clock (see WithClock).
+	Time time.Time
+	Kind UpdateKind
+	// Value is the new value for UpdateSet, and the zero value otherwise. It's always the zero
+	// value in the updates that Map.Events yields for a Map of secrets (see WithSensitive).
+	Value V
+	// Old is the value that was replaced, or for UpdateExpire the value that expired, or the zero
+	// value if there was none. When updates are coalesced, it's the value before the first of them.
+	// It's always the zero value for secrets (see NewSecret).
+	Old V
+	// Err is the error for UpdateError, and nil otherwise.
+	Err error
+	// Digest identifies the new value for UpdateSet on secrets, and on Values with an audit hook
+	// (see WithAuditHook), without revealing it. It's the hex encoded SHA-256 of the value's
+	// default formatting, and empty otherwise.
+	Digest string
+	// Provenance is where the new value came from for UpdateSet, if it was set with one (see
+	// Value.SetWithProvenance).
+	Provenance Provenance
+}
+
+// subscriber receives the updates to a value. Its channel holds at most one pending update, with
+// newer updates replacing older ones that haven't been received yet, so notifying a subscriber
+// never blocks.
+type subscriber[V comparable] struct {
+	ch chan V
+}
+
+// notify delivers i to the subscriber, replacing any pending update. Must be called while holding
+// the value's mutex, which guarantees that there's only ever one goroutine sending.
+func (s *subscriber[V]) notify(i V) {
+	select {
+	case s.ch <- i:
+	default:
+		select {
+		case <-s.ch:
+		default:
+		}
+		s.ch <- i
+	}
+}
+
+// subscribe registers a new subscriber, which immediately receives the current value if set. If
+// the value is closed, the subscriber's channel is closed instead.
+func (v *value[V]) subscribe() *subscriber[V] {
+	sub := &subscriber[V]{ch: make(chan V, 1)}
+
+	v.m.Lock()
+	defer v.m.Unlock()
+	if v.closed.Load() {
+		close(sub.ch)
+		return sub
+	}
+	if v.subscribers == nil {
+		v.subscribers = make(map[*subscriber[V]]struct{})
+	}
+	v.subscribers[sub] = struct{}{}
+	if s := v.snapshot(); s.valid(v.clock.Now()) {
+		sub.notify(s.v)
+	}
+	return sub
+}
+
+func (v *value[V]) unsubscribe(sub *subscriber[V]) {
+	v.m.Lock()
+	delete(v.subscribers, sub)
+	v.m.Unlock()
+}
+
+// pause waits for d to pass according to clock, so that iterators can limit their rate (see
+// WithMaxNotifyRate). It returns false if ctx is done or done is closed first.
+func pause(ctx context.Context, clock Clock, d time.Duration, done <-chan struct{}) bool {
+	if d <= 0 {
+		return true
+	}
+	elapsed := make(chan struct{})
+	timer := clock.AfterFunc(d, func() { close(elapsed) })
+	defer timer.Stop()
+	select {
+	case <-elapsed:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-done:
+		return false
+	}
+}
+
+func (v *value[V]) Updates(ctx context.Context) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		sub := v.subscribe()
+		defer v.unsubscribe(sub)
+		v.follow(ctx, sub, yield)
+	}
+}
+
+func (v *value[V]) Watch(ctx context.Context) <-chan V {
+	ch := make(chan V)
+	// Subscribe right away so that Sets made after Watch returns aren't missed
+	sub := v.subscribe()
+	go func() {
+		defer close(ch)
+		defer v.unsubscribe(sub)
+		v.follow(ctx, sub, func(i V) bool {
+			select {
+			case ch <- i:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return ch
+}
+
+// follow passes the updates received by sub to yield until ctx is done, the value is closed or
+// yield returns false.
+func (v *value[V]) follow(ctx context.Context, sub *subscriber[V], yield func(V) bool) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case i, ok := <-sub.ch:
+			if !ok || !yield(i) {
+				return
+			}
+			if !pause(ctx, v.clock, v.interval, nil) {
+				return
+			}
+		}
+	}
+}
+
+// mapSubscriber receives the updates to a Map's keys. Pending updates are coalesced per key, with
+// newer updates replacing older ones that haven't been received yet, so notifying a mapSubscriber
+// never blocks.
+type mapSubscriber[K comparable, V comparable] struct {
+	mx      sync.Mutex
+	pending map[K]Update[V]
+	order   []K
+	signal  chan struct{}
+}
+
+func newMapSubscriber[K comparable, V comparable]() *mapSubscriber[K, V] {
+	return &mapSubscriber[K, V]{
+		pending: make(map[K]Update[V]),
+		signal:  make(chan struct{}, 1),
+	}
+}
+
+func (s *mapSubscriber[K, V]) notify(key K, u Update[V]) {
+	s.mx.Lock()
+	s.add(key, u)
+	s.mx.Unlock()
+	s.wake()
+}
+
+// notifyBatch delivers several updates at once, so that the consumer receives all of them in the
+// same batch.
+func (s *mapSubscriber[K, V]) notifyBatch(updates []keyedUpdate[K, V]) {
+	s.mx.Lock()
+	for _, ku := range updates {
+		s.add(ku.key, ku.u)
+	}
+	s.mx.Unlock()
+	s.wake()
+}
+
+// add adds an update to the pending ones. Must be called while holding the mutex.
+func (s *mapSubscriber[K, V]) add(key K, u Update[V]) {
+	if previous, found := s.pending[key]; found {
+		// The consumer never saw the previous update, so it's still working from its old value
+		u.Old = previous.Old
+	} else {
+		s.order = append(s.order, key)
+	}
+	s.pending[key] = u
+}
+
+func (s *mapSubscriber[K, V]) wake() {
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+// drain returns the pending updates in the order in which their keys were first updated.
+func (s *mapSubscriber[K, V]) drain() ([]K, map[K]Update[V]) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	order, pending := s.order, s.pending
+	s.order, s.pending = nil, make(map[K]Update[V], len(pending))
+	return order, pending
+}
+
+func (m *emap[K, V]) publish(key K, u Update[V]) {
+	u.Seq = atomic.AddUint64(&m.seq, 1)
+	m.redact(&u)
+	if atomic.LoadInt32(&m.numSubscribers) == 0 {
+		return
+	}
+	m.subscribersMx.RLock()
+	defer m.subscribersMx.RUnlock()
+	for sub := range m.subscribers {
+		sub.notify(key, u)
+	}
+}
+
+// redact removes the new value from u if the Map holds secrets, leaving only its Digest.
+func (m *emap[K, V]) redact(u *Update[V]) {
+	if m.sensitive {
+		var zero V
+		u.Value = zero
+	}
+}
+
+// keyedUpdate is an Update to one of a Map's keys.
+type keyedUpdate[K comparable, V comparable] struct {
+	key K
+	u   Update[V]
+}
+
+// publishBatch is like publish, but delivers the updates to each subscriber at once.
+func (m *emap[K, V]) publishBatch(updates []keyedUpdate[K, V]) {
+	for i := range updates {
+		updates[i].u.Seq = atomic.AddUint64(&m.seq, 1)
+		m.redact(&updates[i].u)
+	}
+	if len(updates) == 0 || atomic.LoadInt32(&m.numSubscribers) == 0 {
+		return
+	}
+	m.subscribersMx.RLock()
+	defer m.subscribersMx.RUnlock()
+	for sub := range m.subscribers {
+		sub.notifyBatch(updates)
+	}
+}
+
+func (m *emap[K, V]) subscribe() *mapSubscriber[K, V] {
+	sub := newMapSubscriber[K, V]()
+	m.subscribersMx.Lock()
+	defer m.subscribersMx.Unlock()
+	if m.subscribers == nil {
+		m.subscribers = make(map[*mapSubscriber[K, V]]struct{})
+	}
+	m.subscribers[sub] = struct{}{}
+	if atomic.AddInt32(&m.numSubscribers, 1) == 1 {
+		// Values only schedule reporting their expiry while they're watched
+		m.scheduleLapses()
+	}
+	return sub
+}
+
+// watched reports whether the Map has Events subscribers.
+func (m *emap[K, V]) watched() bool {
+	return atomic.LoadInt32(&m.numSubscribers) > 0
+}
+
+// scheduleLapses makes sure that every value reports its expiry.
+func (m *emap[K, V]) scheduleLapses() {
+	for _, s := range m.shards {
+		s.mx.Lock()
+		values := make([]*value[V], 0, len(s.m))
+		for _, v := range s.m {
+			values = append(values, v)
+		}
+		s.mx.Unlock()
+		for _, v := range values {
+			v.m.Lock()
+			if v.lapse == nil {
+				v.scheduleLapse()
+			}
+			v.m.Unlock()
+		}
+	}
+}
+
+func (m *emap[K, V]) unsubscribe(sub *mapSubscriber[K, V]) {
+	m.subscribersMx.Lock()
+	defer m.subscribersMx.Unlock()
+	delete(m.subscribers, sub)
+	atomic.AddInt32(&m.numSubscribers, -1)
+}
+
+func (m *emap[K, V]) Events(ctx context.Context) iter.Seq2[K, Update[V]] {
+	return func(yield func(K, Update[V]) bool) {
+		sub := m.subscribe()
+		defer m.unsubscribe(sub)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.done:
+				return
+			case <-sub.signal:
+				order, pending := sub.drain()
+				for _, key := range order {
+					if !yield(key, pending[key]) {
+						return
+					}
+				}
+				if !pause(ctx, m.clock, m.interval, m.done) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package eventual
+
+import (
+	"context"
+	"iter"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdates(t *testing.T) {
+	v := NewValue[int]()
+	v.Set(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan int)
+	go func() {
+		defer close(received)
+		for i := range v.Updates(ctx) {
+			received <- i
+		}
+	}()
+
+	require.Equal(t, 1, <-received, "current value should be yielded first")
+	v.Set(2)
+	require.Equal(t, 2, <-received)
+	v.Set(3)
+	require.Equal(t, 3, <-received)
+
+	cancel()
+	_, open := <-received
+	require.False(t, open, "iteration should end when context is done")
+	require.Empty(t, v.(*value[int]).subscribers, "subscription should be cleaned up")
+}
+
+func TestUpdatesBreak(t *testing.T) {
+	v := NewValue[int]()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		v.Set(1)
+	}()
+	for i := range v.Updates(context.Background()) {
+		require.Equal(t, 1, i)
+		break
+	}
+	require.Empty(t, v.(*value[int]).subscribers, "subscription should be cleaned up")
+}
+
+func TestUpdatesSlowConsumer(t *testing.T) {
+	v := NewValue[int]()
+	v.Set(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	next, stop := iter.Pull(v.Updates(ctx))
+	defer stop()
+	i, ok := next()
+	require.True(t, ok)
+	require.Equal(t, 0, i)
+	for i := 1; i <= 10; i++ {
+		v.Set(i)
+	}
+	i, ok = next()
+	require.True(t, ok)
+	require.Equal(t, 10, i, "slow consumer should only see the latest value")
+}
+
+func TestUpdatesMaxNotifyRate(t *testing.T) {
+	clock := newFakeClock()
+	v := NewValue[int](WithClock(clock), WithMaxNotifyRate(10))
+	v.Set(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	next, stop := iter.Pull(v.Updates(ctx))
+	defer stop()
+	i, ok := next()
+	require.True(t, ok)
+	require.Equal(t, 0, i)
+
+	received := make(chan int)
+	go func() {
+		i, _ := next()
+		received <- i
+	}()
+	require.Eventually(t, func() bool { return clock.pending() == 1 }, time.Second, time.Millisecond)
+	for i := 1; i <= 10; i++ {
+		v.Set(i)
+	}
+	select {
+	case <-received:
+		t.Fatal("update shouldn't be delivered before the interval is up")
+	case <-time.After(20 * time.Millisecond):
+	}
+	clock.Advance(100 * time.Millisecond)
+	require.Equal(t, 10, <-received, "latest value should win between deliveries")
+}
+
+func TestMapEventsMaxNotifyRate(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMap[string, int](WithClock(clock), WithMaxNotifyRate(10))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan string, 10)
+	go func() {
+		for key := range m.Events(ctx) {
+			received <- key
+		}
+	}()
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&m.(*emap[string, int]).numSubscribers) == 1
+	}, time.Second, time.Millisecond)
+	m.Set("a", 1)
+	require.Equal(t, "a", <-received)
+
+	// Wait for the delivery to be throttled, which schedules a timer next to the one for a's expiry
+	require.Eventually(t, func() bool { return clock.pending() == 2 }, time.Second, time.Millisecond)
+	m.Set("b", 1)
+	m.Set("a", 2)
+	select {
+	case <-received:
+		t.Fatal("update shouldn't be delivered before the interval is up")
+	case <-time.After(20 * time.Millisecond):
+	}
+	clock.Advance(100 * time.Millisecond)
+	require.Equal(t, "b", <-received)
+	require.Equal(t, "a", <-received)
+}
+
+func TestMapEvents(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMap[string, int](WithClock(clock))
+	m.Set("before", 0)
+
+	type event struct {
+		key string
+		u   Update[int]
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	next, stop := iter.Pull2(m.Events(ctx))
+	defer stop()
+	nextEvent := func() event {
+		key, u, ok := next()
+		require.True(t, ok)
+		return event{key, u}
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.Set("a", 1)
+	}()
+	require.Equal(t, event{"a", Update[int]{Seq: 2, Time: clock.Now(), Kind: UpdateSet, Value: 1}}, nextEvent())
+
+	m.Set("b", 1)
+	m.Set("b", 2)
+	clock.Advance(time.Second)
+	m.Reset("a")
+	require.Equal(t, event{"b", Update[int]{Seq: 4, Time: clock.Now().Add(-time.Second), Kind: UpdateSet, Value: 2}}, nextEvent(), "updates to b should be coalesced")
+	require.Equal(t, event{"a", Update[int]{Seq: 5, Time: clock.Now(), Kind: UpdateReset, Old: 1}}, nextEvent())
+
+	m.Set("b", 3)
+	require.Equal(t, event{"b", Update[int]{Seq: 6, Time: clock.Now(), Kind: UpdateSet, Value: 3, Old: 2}}, nextEvent())
+
+	stop()
+	require.Empty(t, m.(*emap[string, int]).subscribers, "subscription should be cleaned up")
+	require.Equal(t, "reset", UpdateReset.String())
+}
+
+func TestMapEventsCoalescedOld(t *testing.T) {
+	m := NewMap[string, int]()
+	m.Set("a", 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	next, stop := iter.Pull2(m.Events(ctx))
+	defer stop()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.Set("a", 2)
+		m.Set("a", 3)
+	}()
+	_, u, ok := next()
+	require.True(t, ok)
+	if u.Value == 2 {
+		// The consumer picked up the first update before the second one happened
+		_, u, ok = next()
+		require.True(t, ok)
+		require.Equal(t, 2, u.Old)
+	} else {
+		require.Equal(t, 1, u.Old, "coalesced updates should keep the oldest value")
+	}
+	require.Equal(t, 3, u.Value)
+}
+
+func TestMapEventsExpire(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMap[string, int](WithClock(clock))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	next, stop := iter.Pull2(m.Events(ctx))
+	defer stop()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.SetExpiring("a", 1, clock.Now().Add(time.Second))
+	}()
+	key, u, ok := next()
+	require.True(t, ok)
+	require.Equal(t, "a", key)
+	require.Equal(t, UpdateSet, u.Kind)
+
+	clock.Advance(time.Second)
+	key, u, ok = next()
+	require.True(t, ok)
+	require.Equal(t, "a", key)
+	require.Equal(t, Update[int]{Seq: 2, Time: clock.Now(), Kind: UpdateExpire, Old: 1}, u, "expiry should be reported without a Get")
+	require.Equal(t, "expire", UpdateExpire.String())
+}
+
+func TestMapEventsExpireOnlyWhileWatched(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMap[string, int](WithClock(clock))
+	m.SetExpiring("a", 1, clock.Now().Add(time.Second))
+	m.Set("b", 2)
+	require.Zero(t, clock.pending(), "expiry shouldn't be scheduled without subscribers")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	type event struct {
+		key string
+		u   Update[int]
+	}
+	events := make(chan event, 2)
+	go func() {
+		for key, u := range m.Events(ctx) {
+			events <- event{key, u}
+		}
+	}()
+	require.Eventually(t, func() bool { return clock.pending() == 2 }, time.Second, time.Millisecond,
+		"subscribing should schedule the expiry of existing keys")
+	clock.Advance(time.Second)
+	e := <-events
+	require.Equal(t, "a", e.key)
+	require.Equal(t, UpdateExpire, e.u.Kind)
+}
+
+func TestWatch(t *testing.T) {
+	v := NewValue[int]()
+	v.Set(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := v.Watch(ctx)
+	v.Set(2)
+	i := <-ch
+	if i == 1 {
+		// The current value was picked up before the update happened
+		i = <-ch
+	}
+	require.Equal(t, 2, i, "update made right after Watch returned should be delivered")
+	v.Set(3)
+	require.Equal(t, 3, <-ch)
+
+	cancel()
+	for range ch {
+	}
+	require.Eventually(t, func() bool {
+		v := v.(*value[int])
+		v.m.Lock()
+		defer v.m.Unlock()
+		return len(v.subscribers) == 0
+	}, time.Second, time.Millisecond, "subscription should be cleaned up")
+
+	v.Close()
+	_, open := <-v.Watch(context.Background())
+	require.False(t, open, "channel should be closed for a closed value")
+}
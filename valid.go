@@ -0,0 +1,51 @@
+package eventual
+
+import (
+	"context"
+	"fmt"
+)
+
+func (v *value[V]) GetValid(ctx context.Context, validate func(V) error) (V, error) {
+	return v.getValid(ctx, validate, nil)
+}
+
+// getValid implements GetValid, calling onInvalid, if not nil, whenever a value fails validation.
+func (v *value[V]) getValid(ctx context.Context, validate func(V) error, onInvalid func()) (V, error) {
+	for {
+		// Read the version first, so that we can't miss a Set that happens after Get
+		version := v.Version()
+		result, err := v.Get(ctx)
+		if err != nil {
+			return v.zeroValue, err
+		}
+		invalid := validate(result)
+		if invalid == nil {
+			return result, nil
+		}
+		if onInvalid != nil {
+			onInvalid()
+		}
+		if err := v.awaitVersion(ctx, version+1); err != nil {
+			if err == ErrClosed {
+				return v.zeroValue, err
+			}
+			return v.zeroValue, fmt.Errorf("eventual: no valid value: %w (last validation error: %w)", err, invalid)
+		}
+	}
+}
+
+func (r *refreshing[V]) GetValid(ctx context.Context, validate func(V) error) (V, error) {
+	return r.getValid(ctx, validate, r.refreshNow)
+}
+
+// refreshNow starts a refresh right away, unless one is already running or r was stopped.
+func (r *refreshing[V]) refreshNow() {
+	r.timerMx.Lock()
+	defer r.timerMx.Unlock()
+	if r.stopped || r.timer == nil || !r.timer.Stop() {
+		// Stop returns false if the timer fired already, which means a refresh is running
+		return
+	}
+	r.timer = nil
+	go r.refresh()
+}
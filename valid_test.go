@@ -0,0 +1,60 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetValid(t *testing.T) {
+	revoked := errors.New("revoked")
+	validate := func(cert string) error {
+		if cert == "revoked" {
+			return revoked
+		}
+		return nil
+	}
+
+	v := NewValueFrom("good")
+	r, err := v.GetValid(DontWait, validate)
+	require.NoError(t, err)
+	require.Equal(t, "good", r)
+
+	v.Set("revoked")
+	_, err = v.GetValid(DontWait, validate)
+	require.True(t, errors.Is(err, context.Canceled))
+	require.True(t, errors.Is(err, revoked), "last validation error should be included")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		v.Set("revoked")
+		time.Sleep(10 * time.Millisecond)
+		v.Set("renewed")
+	}()
+	r, err = v.GetValid(context.Background(), validate)
+	require.NoError(t, err)
+	require.Equal(t, "renewed", r)
+}
+
+func TestRefreshingGetValid(t *testing.T) {
+	clock := newFakeClock()
+	var calls int32
+	v := NewRefreshing[int](time.Hour, func(ctx context.Context) (int, error) {
+		return int(atomic.AddInt32(&calls, 1)), nil
+	}, WithClock(clock))
+	defer v.Stop()
+
+	require.Eventually(t, func() bool { return clock.pending() == 1 }, time.Second, time.Millisecond)
+	r, err := v.GetValid(context.Background(), func(i int) error {
+		if i < 2 {
+			return errors.New("too old")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, r, "invalid value should be refreshed right away")
+}
@@ -0,0 +1,69 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrStaleRevision is returned by Versioned.SetVersioned when the given revision isn't newer than
+// the one already stored.
+var ErrStaleRevision = errors.New("eventual: stale revision")
+
+// Versioned is an eventual value whose updates carry a revision number, for optimistic concurrency
+// control. Updates with a revision that isn't newer than the latest one are rejected, so producers
+// racing each other can never roll the value back.
+type Versioned[V comparable] interface {
+	// SetVersioned sets the value at the given revision. If rev isn't greater than the latest
+	// revision that was set, nothing is stored and ErrStaleRevision is returned.
+	SetVersioned(value V, rev uint64) error
+
+	// Get waits for the value to be set and returns it along with its revision. If the context
+	// expires first, an error will be returned. See Value.Get.
+	Get(ctx context.Context) (V, uint64, error)
+
+	// Reset clears the currently set value. The latest revision is remembered, so subsequent
+	// updates must still be newer than it.
+	Reset()
+}
+
+// NewVersioned creates a new Versioned value. Defaults set with SetDefaults don't apply to it, since
+// they are meant for values of type V, not for the revisions it stores.
+func NewVersioned[V comparable]() Versioned[V] {
+	return &versioned[V]{v: newValueExact[revision[V]](nil)}
+}
+
+type revision[V comparable] struct {
+	value V
+	rev   uint64
+}
+
+type versioned[V comparable] struct {
+	mx     sync.Mutex
+	v      Value[revision[V]]
+	rev    uint64
+	hasRev bool
+}
+
+func (vv *versioned[V]) SetVersioned(value V, rev uint64) error {
+	vv.mx.Lock()
+	defer vv.mx.Unlock()
+	if vv.hasRev && rev <= vv.rev {
+		return ErrStaleRevision
+	}
+	vv.rev = rev
+	vv.hasRev = true
+	vv.v.Set(revision[V]{value: value, rev: rev})
+	return nil
+}
+
+func (vv *versioned[V]) Get(ctx context.Context) (V, uint64, error) {
+	r, err := vv.v.Get(ctx)
+	return r.value, r.rev, err
+}
+
+func (vv *versioned[V]) Reset() {
+	vv.mx.Lock()
+	defer vv.mx.Unlock()
+	vv.v.Reset()
+}
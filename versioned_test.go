@@ -0,0 +1,47 @@
+package eventual
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersioned(t *testing.T) {
+	v := NewVersioned[string]()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		require.NoError(t, v.SetVersioned("a", 5))
+	}()
+	r, rev, err := v.Get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "a", r)
+	require.EqualValues(t, 5, rev)
+
+	require.Equal(t, ErrStaleRevision, v.SetVersioned("stale", 4))
+	require.Equal(t, ErrStaleRevision, v.SetVersioned("same", 5))
+	require.NoError(t, v.SetVersioned("b", 6))
+	r, rev, err = v.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "b", r)
+	require.EqualValues(t, 6, rev)
+
+	v.Reset()
+	_, _, err = v.Get(DontWait)
+	require.Error(t, err)
+	require.Equal(t, ErrStaleRevision, v.SetVersioned("older", 3), "revisions should survive Reset")
+}
+
+func TestVersionedIgnoresDefaults(t *testing.T) {
+	t.Cleanup(func() { SetDefaults() })
+	SetDefaults(WithMaxAgePolicy(time.Millisecond))
+	v := NewVersioned[string]()
+	require.NoError(t, v.SetVersioned("a", 1))
+	time.Sleep(5 * time.Millisecond)
+	r, rev, err := v.Get(DontWait)
+	require.NoError(t, err, "revision shouldn't expire")
+	require.Equal(t, "a", r)
+	require.EqualValues(t, 1, rev)
+}
@@ -0,0 +1,88 @@
+package eventual
+
+import (
+	"context"
+	"time"
+)
+
+// Getter is a read-only view of a Value, which APIs can hand to consumers that mustn't be able to
+// write to it (see Value.ReadOnly).
+type Getter[V any] interface {
+	// Get waits for the Value to be set, see Value.Get.
+	Get(ctx context.Context) (V, error)
+
+	// Peek returns the current value without blocking, see Value.Peek.
+	Peek() (V, bool)
+
+	// Wait waits for the Value to be set without returning it, see Value.Wait.
+	Wait(ctx context.Context) error
+}
+
+// Setter is a write-only view of a Value, which APIs can hand to producers that have no business
+// reading it (see Value.WriteOnly).
+type Setter[V any] interface {
+	// Set the Value.
+	Set(value V)
+
+	// Set the Value, expiring at the given time.
+	SetExpiring(value V, expiration time.Time)
+
+	// SetError makes the Value fail with err, see Value.SetError.
+	SetError(err error)
+
+	// Reset clears the currently set value, see Value.Reset.
+	Reset()
+}
+
+// readOnly and writeOnly wrap a Value so that consumers can't type assert their way back to it.
+type readOnly[V any] struct {
+	g Getter[V]
+}
+
+func (r readOnly[V]) Get(ctx context.Context) (V, error) {
+	return r.g.Get(ctx)
+}
+
+func (r readOnly[V]) Peek() (V, bool) {
+	return r.g.Peek()
+}
+
+func (r readOnly[V]) Wait(ctx context.Context) error {
+	return r.g.Wait(ctx)
+}
+
+type writeOnly[V any] struct {
+	s Setter[V]
+}
+
+func (w writeOnly[V]) Set(value V) {
+	w.s.Set(value)
+}
+
+func (w writeOnly[V]) SetExpiring(value V, expiration time.Time) {
+	w.s.SetExpiring(value, expiration)
+}
+
+func (w writeOnly[V]) SetError(err error) {
+	w.s.SetError(err)
+}
+
+func (w writeOnly[V]) Reset() {
+	w.s.Reset()
+}
+
+func (v *value[V]) ReadOnly() Getter[V] {
+	return readOnly[V]{v}
+}
+
+func (v *value[V]) WriteOnly() Setter[V] {
+	return writeOnly[V]{v}
+}
+
+func (a *anyValue[V]) ReadOnly() Getter[V] {
+	return readOnly[V]{a}
+}
+
+func (a *anyValue[V]) WriteOnly() Setter[V] {
+	return writeOnly[V]{a}
+}
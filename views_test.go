@@ -0,0 +1,47 @@
+package eventual
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestViews(t *testing.T) {
+	v := NewValue[string]()
+	r := v.ReadOnly()
+	w := v.WriteOnly()
+	_, isValue := r.(Value[string])
+	require.False(t, isValue, "read-only view shouldn't expose the Value")
+	_, isValue = w.(Value[string])
+	require.False(t, isValue, "write-only view shouldn't expose the Value")
+
+	_, ok := r.Peek()
+	require.False(t, ok)
+	w.Set("a")
+	require.NoError(t, r.Wait(context.Background()))
+	result, err := r.Get(DontWait)
+	require.NoError(t, err)
+	require.Equal(t, "a", result)
+
+	w.SetExpiring("b", time.Now().Add(-time.Second))
+	_, ok = r.Peek()
+	require.False(t, ok)
+
+	fail := errors.New("fail")
+	w.SetError(fail)
+	_, err = r.Get(DontWait)
+	require.True(t, errors.Is(err, fail))
+	w.Reset()
+	require.False(t, v.IsSet())
+}
+
+func TestAnyValueViews(t *testing.T) {
+	v := NewAnyValue[[]int]()
+	v.WriteOnly().Set([]int{1})
+	result, ok := v.ReadOnly().Peek()
+	require.True(t, ok)
+	require.Equal(t, []int{1}, result)
+}